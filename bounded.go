@@ -0,0 +1,96 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import "iter"
+
+// BoundedSortedSet is a sorted set that holds at most k elements: once it
+// is full, adding an element that is larger than its smallest element
+// drops the smallest element, and adding any other element does nothing.
+// So after adding a stream of elements it holds the k largest of them.
+//
+// BoundedSortedSet's zero value is not usable since it has no bound;
+// create with [NewBounded]. For example:
+//
+//	top := NewBounded[int](10)
+//	for _, score := range scores {
+//		top.Add(score)
+//	}
+//	best := top.ToSlice() // the 10 highest scores in ascending order
+type BoundedSortedSet[E Comparable] struct {
+	sset SortedSet[E]
+	k    int
+}
+
+// NewBounded returns a new empty BoundedSortedSet that will hold at most k
+// elements. NewBounded panics if k is less than 1.
+func NewBounded[E Comparable](k int) *BoundedSortedSet[E] {
+	if k < 1 {
+		panic("sortedset: NewBounded requires a positive bound")
+	}
+	return &BoundedSortedSet[E]{k: k}
+}
+
+// Add adds a new element into the BoundedSortedSet and returns true,
+// dropping the smallest element if the BoundedSortedSet was full; or does
+// nothing and returns false if the element is already present, or if the
+// BoundedSortedSet is full and the element isn't larger than its smallest
+// element.
+func (me *BoundedSortedSet[E]) Add(element E) bool {
+	if me.sset.Len() < me.k {
+		return me.sset.Add(element)
+	}
+	if smallest, _ := me.sset.Min(); !(smallest < element) ||
+		!me.sset.Add(element) {
+		return false
+	}
+	me.sset.DeleteMin()
+	return true
+}
+
+// Bound returns the maximum number of elements the BoundedSortedSet can
+// hold.
+func (me *BoundedSortedSet[E]) Bound() int { return me.k }
+
+// Len returns the number of elements in the BoundedSortedSet.
+func (me *BoundedSortedSet[E]) Len() int { return me.sset.Len() }
+
+// IsFull returns true if the BoundedSortedSet holds as many elements as
+// its bound; otherwise returns false.
+func (me *BoundedSortedSet[E]) IsFull() bool { return me.sset.Len() == me.k }
+
+// Contains returns true if the element is in the BoundedSortedSet;
+// otherwise false.
+func (me *BoundedSortedSet[E]) Contains(element E) bool {
+	return me.sset.Contains(element)
+}
+
+// Min returns the BoundedSortedSet's minimum element and true; or the zero
+// value and false if the BoundedSortedSet is empty. Once the
+// BoundedSortedSet is full, this is the threshold that an element must
+// exceed to be added.
+func (me *BoundedSortedSet[E]) Min() (E, bool) { return me.sset.Min() }
+
+// Max returns the BoundedSortedSet's maximum element and true; or the zero
+// value and false if the BoundedSortedSet is empty.
+func (me *BoundedSortedSet[E]) Max() (E, bool) { return me.sset.Max() }
+
+// All returns a for .. range iterable of the BoundedSortedSet's elements
+// in ascending order, e.g., for element := range top.All()
+func (me *BoundedSortedSet[E]) All() iter.Seq[E] { return me.sset.All() }
+
+// Backward returns a for .. range iterable of the BoundedSortedSet's
+// elements in descending order, e.g., for element := range top.Backward()
+func (me *BoundedSortedSet[E]) Backward() iter.Seq[E] {
+	return me.sset.Backward()
+}
+
+// Clear deletes all the elements in the BoundedSortedSet; its bound is
+// unchanged.
+func (me *BoundedSortedSet[E]) Clear() { me.sset.Clear() }
+
+// ToSlice returns the BoundedSortedSet's elements as a sorted slice.
+func (me *BoundedSortedSet[E]) ToSlice() []E { return me.sset.ToSlice() }
+
+// String returns a human readable string representation of the
+// BoundedSortedSet.
+func (me *BoundedSortedSet[E]) String() string { return me.sset.String() }
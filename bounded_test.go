@@ -0,0 +1,58 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBounded(t *testing.T) {
+	top := NewBounded[int](3)
+	if top.Bound() != 3 || top.IsFull() {
+		t.Errorf("expected bound 3 and not full, got %d %t", top.Bound(),
+			top.IsFull())
+	}
+	for _, datum := range []struct {
+		element int
+		added   bool
+		exp     string
+	}{
+		{5, true, "{5}"},
+		{1, true, "{1 5}"},
+		{5, false, "{1 5}"},
+		{3, true, "{1 3 5}"},
+		{0, false, "{1 3 5}"},
+		{1, false, "{1 3 5}"},
+		{3, false, "{1 3 5}"},
+		{4, true, "{3 4 5}"},
+		{9, true, "{4 5 9}"},
+	} {
+		if added := top.Add(datum.element); added != datum.added {
+			t.Errorf("Add(%d): expected %t, got %t", datum.element,
+				datum.added, added)
+		}
+		check(top.String(), top.Len(), datum.exp, len(top.ToSlice()), t)
+	}
+	if !top.IsFull() || !top.Contains(9) || top.Contains(1) {
+		t.Errorf("expected full with 9 and not 1: %v", top)
+	}
+	if x, ok := top.Min(); !ok || x != 4 {
+		t.Errorf("expected 4 true, got %d %t", x, ok)
+	}
+	top.Clear()
+	check(top.String(), top.Len(), "{}", 0, t)
+	rng := rand.New(rand.NewSource(1))
+	top = NewBounded[int](10)
+	var all []int
+	for range 1000 {
+		x := rng.Intn(500)
+		all = append(all, x)
+		top.Add(x)
+	}
+	slices.Sort(all)
+	all = slices.Compact(all)
+	if actual := top.ToSlice(); !slices.Equal(actual, all[len(all)-10:]) {
+		t.Errorf("expected %v, got %v", all[len(all)-10:], actual)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import "slices"
+
+// Builder accumulates elements for a SortedSet that is built in one go by
+// [Builder.Build]. Adding elements to a Builder is just appending to a
+// slice, and Build bulk-loads a balanced tree, so no rotations are ever
+// needed. This is much faster than adding the elements to a SortedSet one
+// at a time when a set is loaded once and then read many times.
+//
+// Builder's zero value is usable. For example:
+//
+//	var builder Builder[int]
+//	for _, x := range data {
+//		builder.Add(x)
+//	}
+//	sset := builder.Build()
+type Builder[E Comparable] struct {
+	elements []E
+}
+
+// NewBuilder returns a new Builder with room for capacity elements before
+// it needs to grow.
+func NewBuilder[E Comparable](capacity int) Builder[E] {
+	return Builder[E]{elements: make([]E, 0, capacity)}
+}
+
+// Add adds the given element to the Builder. Duplicates are allowed and
+// are removed by [Builder.Build].
+func (me *Builder[E]) Add(element E) {
+	me.elements = append(me.elements, element)
+}
+
+// AddAll adds each of the given elements to the Builder.
+func (me *Builder[E]) AddAll(elements ...E) {
+	me.elements = append(me.elements, elements...)
+}
+
+// Len returns the number of elements added to the Builder so far,
+// including any duplicates.
+func (me *Builder[E]) Len() int { return len(me.elements) }
+
+// Build returns a new SortedSet containing the Builder's elements and
+// empties the Builder so that it can be reused.
+func (me *Builder[E]) Build() SortedSet[E] {
+	elements := me.elements
+	me.elements = nil
+	slices.Sort(elements)
+	return fromSorted(slices.Compact(elements))
+}
@@ -0,0 +1,52 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	var builder Builder[int]
+	s := builder.Build()
+	check(s.String(), s.Len(), "{}", 0, t)
+	builder.Add(5)
+	builder.AddAll(3, 9, 3, 1, 5)
+	if builder.Len() != 6 {
+		t.Errorf("expected 6, got %d", builder.Len())
+	}
+	s = builder.Build()
+	checkLenAndOrder(&s, 4, t)
+	check(s.String(), s.Len(), "{1 3 5 9}", 4, t)
+	if builder.Len() != 0 {
+		t.Errorf("expected empty builder, got %d", builder.Len())
+	}
+	builder.Add(2)
+	u := builder.Build()
+	check(u.String(), u.Len(), "{2}", 1, t)
+	check(s.String(), s.Len(), "{1 3 5 9}", 4, t)
+	rng := rand.New(rand.NewSource(1))
+	builder = NewBuilder[int](1000)
+	expected := New[int]()
+	for range 1000 {
+		x := rng.Intn(500)
+		builder.Add(x)
+		expected.Add(x)
+	}
+	s = builder.Build()
+	checkLenAndOrder(&s, expected.Len(), t)
+	if !s.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, s)
+	}
+}
+
+func BenchmarkBuilder100k(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		builder := NewBuilder[int](100000)
+		for i := range 100000 {
+			builder.Add(i)
+		}
+		builder.Build()
+	}
+}
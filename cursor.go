@@ -0,0 +1,115 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+// Cursor supports moving forward and backward through a SortedSet's
+// elements one at a time. A Cursor is always positioned between two
+// elements (or before the first or after the last), so calling
+// [Cursor.Next] then [Cursor.Prev] returns the same element twice. Create
+// one with [SortedSet.Cursor], e.g.,
+//
+//	cursor := sset.Cursor()
+//	for element, ok := cursor.Next(); ok; element, ok = cursor.Next() {
+//		...
+//	}
+//
+// A Cursor is invalidated if its SortedSet is changed (e.g., by
+// [SortedSet.Add] or [SortedSet.Delete]), after which it must not be used.
+type Cursor[E Comparable] struct {
+	root *node[E]
+	// path goes from the root to the element after the cursor; it is empty
+	// if the cursor is after the last element.
+	path []*node[E]
+}
+
+// Cursor returns a new Cursor positioned before the SortedSet's first
+// element.
+func (me *SortedSet[E]) Cursor() *Cursor[E] {
+	cursor := &Cursor[E]{root: me.root,
+		path: make([]*node[E], 0, maxHeight)}
+	cursor.pushLeft(me.root)
+	return cursor
+}
+
+// Next returns the element after the cursor and true, and moves the cursor
+// past it; or returns the zero value and false if the cursor is after the
+// last element.
+func (me *Cursor[E]) Next() (E, bool) {
+	if len(me.path) == 0 {
+		var zero E
+		return zero, false
+	}
+	root := me.path[len(me.path)-1]
+	if root.right != nil {
+		me.pushLeft(root.right)
+	} else { // Go up until we come up from a left child
+		for {
+			child := me.path[len(me.path)-1]
+			me.path = me.path[:len(me.path)-1]
+			if len(me.path) == 0 || me.path[len(me.path)-1].left == child {
+				break
+			}
+		}
+	}
+	return root.element, true
+}
+
+// Prev returns the element before the cursor and true, and moves the
+// cursor back before it; or returns the zero value and false if the cursor
+// is before the first element.
+func (me *Cursor[E]) Prev() (E, bool) {
+	var zero E
+	if len(me.path) == 0 { // After the last element
+		if me.root == nil {
+			return zero, false
+		}
+		me.pushRight(me.root)
+		return me.path[len(me.path)-1].element, true
+	}
+	if root := me.path[len(me.path)-1]; root.left != nil {
+		me.pushRight(root.left)
+		return me.path[len(me.path)-1].element, true
+	}
+	for { // Go up until we come up from a right child
+		child := me.path[len(me.path)-1]
+		me.path = me.path[:len(me.path)-1]
+		if len(me.path) == 0 { // Was before the first element so restore
+			me.pushLeft(me.root)
+			return zero, false
+		}
+		if root := me.path[len(me.path)-1]; root.right == child {
+			return root.element, true
+		}
+	}
+}
+
+// SeekTo moves the cursor to just before the smallest element that is
+// greater than or equal to x (or after the last element if there is no
+// such element). So afterwards, [Cursor.Next] returns the smallest element
+// that is greater than or equal to x, and [Cursor.Prev] returns the
+// largest element that is less than x.
+func (me *Cursor[E]) SeekTo(x E) {
+	me.path = me.path[:0]
+	depth := 0 // of the last node whose element is >= x
+	for root := me.root; root != nil; {
+		me.path = append(me.path, root)
+		if root.element < x {
+			root = root.right
+		} else {
+			depth = len(me.path)
+			root = root.left
+		}
+	}
+	me.path = me.path[:depth]
+}
+
+func (me *Cursor[E]) pushLeft(root *node[E]) {
+	for ; root != nil; root = root.left {
+		me.path = append(me.path, root)
+	}
+}
+
+func (me *Cursor[E]) pushRight(root *node[E]) {
+	for ; root != nil; root = root.right {
+		me.path = append(me.path, root)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	s := New(10, 20, 30)
+	cursor := s.Cursor()
+	if x, ok := cursor.Prev(); ok {
+		t.Errorf("unexpected element before the first: %d", x)
+	}
+	var actual []string
+	for x, ok := cursor.Next(); ok; x, ok = cursor.Next() {
+		actual = append(actual, fmt.Sprint(x))
+	}
+	for x, ok := cursor.Prev(); ok; x, ok = cursor.Prev() {
+		actual = append(actual, fmt.Sprint(x))
+	}
+	if x, ok := cursor.Next(); !ok || x != 10 {
+		t.Errorf("expected 10 true, got %d %t", x, ok)
+	}
+	if x, ok := cursor.Prev(); !ok || x != 10 {
+		t.Errorf("expected 10 true, got %d %t", x, ok)
+	}
+	expected := "[10 20 30 30 20 10]"
+	if fmt.Sprint(actual) != expected {
+		t.Errorf("expected %s, got %v", expected, actual)
+	}
+	for _, datum := range []struct {
+		x, next, prev    int
+		hasNext, hasPrev bool
+	}{
+		{5, 10, 0, true, false},
+		{10, 10, 0, true, false},
+		{15, 20, 10, true, true},
+		{30, 30, 20, true, true},
+		{35, 0, 30, false, true},
+	} {
+		cursor.SeekTo(datum.x)
+		next, ok := cursor.Next()
+		if next != datum.next || ok != datum.hasNext {
+			t.Errorf("SeekTo(%d) Next: expected %d %t, got %d %t", datum.x,
+				datum.next, datum.hasNext, next, ok)
+		}
+		cursor.SeekTo(datum.x)
+		prev, ok := cursor.Prev()
+		if prev != datum.prev || ok != datum.hasPrev {
+			t.Errorf("SeekTo(%d) Prev: expected %d %t, got %d %t", datum.x,
+				datum.prev, datum.hasPrev, prev, ok)
+		}
+	}
+	var empty SortedSet[int]
+	cursor = empty.Cursor()
+	if _, ok := cursor.Next(); ok {
+		t.Error("unexpected Next element in empty set")
+	}
+	if _, ok := cursor.Prev(); ok {
+		t.Error("unexpected Prev element in empty set")
+	}
+}
+
+func TestCursorRandomWalk(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	s := FromSlice(rng.Perm(200))
+	for range 50 {
+		s.Delete(rng.Intn(200))
+	}
+	elements := s.ToSlice()
+	cursor := s.Cursor()
+	i := 0 // index of the element after the cursor
+	for range 5000 {
+		switch rng.Intn(3) {
+		case 0:
+			x, ok := cursor.Next()
+			if ok != (i < len(elements)) || (ok && x != elements[i]) {
+				t.Fatalf("Next at %d: got %d %t", i, x, ok)
+			}
+			if ok {
+				i++
+			}
+		case 1:
+			x, ok := cursor.Prev()
+			if ok != (i > 0) || (ok && x != elements[i-1]) {
+				t.Fatalf("Prev at %d: got %d %t", i, x, ok)
+			}
+			if ok {
+				i--
+			}
+		default:
+			x := rng.Intn(220) - 10
+			cursor.SeekTo(x)
+			i, _ = slices.BinarySearch(elements, x)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+//go:build sortedset_debug
+
+package sortedset
+
+// debug is true when built with -tags sortedset_debug, which enables
+// extra (and expensive) consistency checks, e.g., that a SortedSetFunc's
+// elements are still in order after iterating over them.
+const debug = true
@@ -0,0 +1,99 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import "iter"
+
+// FrozenSortedSet is a read-only view of a SortedSet, so it only has
+// methods that don't change it. Pass a FrozenSortedSet rather than a
+// *SortedSet to guarantee to callers that the set they have won't change
+// underneath them, and to prevent them from changing it.
+//
+// A FrozenSortedSet is created with [SortedSet.Freeze]. Its zero value is
+// an empty set.
+type FrozenSortedSet[E Comparable] struct {
+	sset SortedSet[E]
+}
+
+// Freeze returns a read-only view of this SortedSet in O(1) time. The view
+// shares all its nodes with this SortedSet (just like a
+// [SortedSet.Snapshot]), so nothing is copied. Freezing doesn't prevent
+// this SortedSet from being changed, but since any changes are
+// copy-on-write they never affect the view. Note that, like Snapshot,
+// Freeze counts as a change to this SortedSet, so must not be called while
+// another goroutine is reading it (e.g., while holding only a read lock).
+// For example:
+//
+//	known := sset.Freeze()
+//	sset.Add(x) // known is unchanged
+//
+// See also [FrozenSortedSet.Thaw].
+func (me *SortedSet[E]) Freeze() FrozenSortedSet[E] {
+	return FrozenSortedSet[E]{sset: me.Snapshot()}
+}
+
+// Thaw returns a new SortedSet containing the FrozenSortedSet's elements
+// in O(1) time. The new SortedSet shares its nodes with the
+// FrozenSortedSet (copying only those it changes), so changing it never
+// affects the FrozenSortedSet.
+func (me FrozenSortedSet[E]) Thaw() SortedSet[E] { return me.sset.Snapshot() }
+
+// Len returns the number of elements in the FrozenSortedSet.
+func (me FrozenSortedSet[E]) Len() int { return me.sset.Len() }
+
+// IsEmpty returns true if there are no elements in the FrozenSortedSet;
+// otherwise returns false.
+func (me FrozenSortedSet[E]) IsEmpty() bool { return me.sset.IsEmpty() }
+
+// Contains returns true if the element is in the FrozenSortedSet;
+// otherwise false.
+func (me FrozenSortedSet[E]) Contains(element E) bool {
+	return me.sset.Contains(element)
+}
+
+// Min returns the FrozenSortedSet's smallest element and true; or the zero
+// value and false if the FrozenSortedSet is empty.
+func (me FrozenSortedSet[E]) Min() (E, bool) { return me.sset.Min() }
+
+// Max returns the FrozenSortedSet's largest element and true; or the zero
+// value and false if the FrozenSortedSet is empty.
+func (me FrozenSortedSet[E]) Max() (E, bool) { return me.sset.Max() }
+
+// Floor returns the largest element that is less than or equal to x and
+// true; or the zero value and false if there is no such element.
+func (me FrozenSortedSet[E]) Floor(x E) (E, bool) { return me.sset.Floor(x) }
+
+// Ceiling returns the smallest element that is greater than or equal to x
+// and true; or the zero value and false if there is no such element.
+func (me FrozenSortedSet[E]) Ceiling(x E) (E, bool) {
+	return me.sset.Ceiling(x)
+}
+
+// At returns the element at sorted index i (counting from 0) and true; or
+// the zero value and false if i is out of range.
+func (me FrozenSortedSet[E]) At(i int) (E, bool) { return me.sset.At(i) }
+
+// Rank returns the number of elements that are less than x.
+func (me FrozenSortedSet[E]) Rank(x E) int { return me.sset.Rank(x) }
+
+// All returns a for .. range iterable of the FrozenSortedSet's elements,
+// e.g., for element := range frozen.All()
+func (me FrozenSortedSet[E]) All() iter.Seq[E] { return me.sset.All() }
+
+// Backward returns a for .. range iterable of the FrozenSortedSet's
+// elements in descending order, e.g., for element := range frozen.Backward()
+func (me FrozenSortedSet[E]) Backward() iter.Seq[E] {
+	return me.sset.Backward()
+}
+
+// Between returns a for .. range iterable of the FrozenSortedSet's
+// elements that are greater than or equal to lo and less than hi.
+func (me FrozenSortedSet[E]) Between(lo, hi E) iter.Seq[E] {
+	return me.sset.Between(lo, hi)
+}
+
+// ToSlice returns the FrozenSortedSet's elements as a sorted slice.
+func (me FrozenSortedSet[E]) ToSlice() []E { return me.sset.ToSlice() }
+
+// String returns a human readable string representation of the
+// FrozenSortedSet.
+func (me FrozenSortedSet[E]) String() string { return me.sset.String() }
@@ -0,0 +1,41 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	var empty FrozenSortedSet[int]
+	check(empty.String(), empty.Len(), "{}", 0, t)
+	s := New(5, 1, 3)
+	frozen := s.Freeze()
+	s.Add(2)
+	s.Delete(5)
+	check(s.String(), s.Len(), "{1 2 3}", 3, t)
+	check(frozen.String(), frozen.Len(), "{1 3 5}", 3, t)
+	if !frozen.Contains(5) || frozen.Contains(2) || frozen.IsEmpty() {
+		t.Errorf("unexpected frozen contents: %v", frozen)
+	}
+	if x, ok := frozen.Max(); !ok || x != 5 {
+		t.Errorf("expected 5 true, got %d %t", x, ok)
+	}
+	if x, ok := frozen.Ceiling(4); !ok || x != 5 {
+		t.Errorf("expected 5 true, got %d %t", x, ok)
+	}
+	if x, ok := frozen.At(1); !ok || x != 3 || frozen.Rank(4) != 2 {
+		t.Errorf("expected 3 true 2, got %d %t %d", x, ok, frozen.Rank(4))
+	}
+	if actual := slices.Collect(frozen.Backward()); !slices.Equal(actual,
+		[]int{5, 3, 1}) {
+		t.Errorf("expected [5 3 1], got %v", actual)
+	}
+	thawed := frozen.Thaw()
+	thawed.Add(4)
+	thawed.Delete(1)
+	checkLenAndOrder(&thawed, 3, t)
+	check(thawed.String(), thawed.Len(), "{3 4 5}", 3, t)
+	check(frozen.String(), frozen.Len(), "{1 3 5}", 3, t)
+	check(s.String(), s.Len(), "{1 2 3}", 3, t)
+}
@@ -0,0 +1,349 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MarshalJSON returns the SortedSet as a JSON array of its elements in
+// strictly ascending order (or [] if it is empty). So SortedSets with the
+// same elements always marshal to identical bytes no matter how they were
+// built, making the output suitable for use as, say, a cache key.
+// See also [SortedSet.UnmarshalJSON].
+func (me SortedSet[E]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(me.ToSlice())
+}
+
+// UnmarshalJSON replaces the SortedSet's elements with those in the given
+// JSON array. An error is returned (and the SortedSet is left unchanged)
+// if the data isn't a JSON array of elements of type E. As is conventional
+// for encoding/json, JSON null does nothing.
+// See also [SortedSet.MarshalJSON].
+func (me *SortedSet[E]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var elements []E
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return fmt.Errorf("sortedset: expected JSON array: %w", err)
+	}
+	me.replace(FromSlice(elements))
+	return nil
+}
+
+// GobEncode returns the SortedSet's elements in sorted order gob-encoded.
+// See also [SortedSet.GobDecode].
+func (me SortedSet[E]) GobEncode() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(me.ToSlice()); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// GobDecode replaces the SortedSet's elements with the gob-encoded
+// elements in data.
+// See also [SortedSet.GobEncode].
+func (me *SortedSet[E]) GobDecode(data []byte) error {
+	var elements []E
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(
+		&elements); err != nil {
+		return err
+	}
+	me.replace(FromSlice(elements))
+	return nil
+}
+
+// MarshalText returns the SortedSet in the same form as [SortedSet.String],
+// e.g., {1 2 3} or {"a" "b" "c"}.
+// See also [SortedSet.UnmarshalText].
+func (me SortedSet[E]) MarshalText() ([]byte, error) {
+	return []byte(me.String()), nil
+}
+
+// UnmarshalText replaces the SortedSet's elements with those in text which
+// must be in the form produced by [SortedSet.MarshalText]. String elements
+// may be quoted (as [SortedSet.String] does) or bare words.
+func (me *SortedSet[E]) UnmarshalText(text []byte) error {
+	body := strings.TrimSpace(string(text))
+	if !strings.HasPrefix(body, "{") || !strings.HasSuffix(body, "}") {
+		return fmt.Errorf("sortedset: expected {elements}, got %q", text)
+	}
+	body = strings.TrimSpace(body[1 : len(body)-1])
+	var elements []E
+	for body != "" {
+		element, rest, err := parseTextElement[E](body)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, element)
+		body = strings.TrimSpace(rest)
+	}
+	me.replace(FromSlice(elements))
+	return nil
+}
+
+func parseTextElement[E Comparable](text string) (E, string, error) {
+	var element E
+	value := reflect.ValueOf(&element).Elem()
+	if text[0] == '"' {
+		quoted, err := strconv.QuotedPrefix(text)
+		if err != nil {
+			return element, "", fmt.Errorf("sortedset: invalid string: %w",
+				err)
+		}
+		if value.Kind() != reflect.String {
+			return element, "", fmt.Errorf(
+				"sortedset: unexpected string %s", quoted)
+		}
+		unquoted, _ := strconv.Unquote(quoted)
+		value.SetString(unquoted)
+		return element, text[len(quoted):], nil
+	}
+	token, rest := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i > -1 {
+		token, rest = text[:i], text[i:]
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(token)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		i, err := strconv.ParseInt(token, 10, value.Type().Bits())
+		if err != nil {
+			return element, "", fmt.Errorf(
+				"sortedset: invalid element %q: %w", token, err)
+		}
+		value.SetInt(i)
+	default:
+		u, err := strconv.ParseUint(token, 10, value.Type().Bits())
+		if err != nil {
+			return element, "", fmt.Errorf(
+				"sortedset: invalid element %q: %w", token, err)
+		}
+		value.SetUint(u)
+	}
+	return element, rest, nil
+}
+
+// WriteTo writes the SortedSet's elements to w in a compact binary format
+// and returns the number of bytes written. The format is the number of
+// elements as a uvarint (see [binary.AppendUvarint]) followed by each
+// element in ascending order. Integer elements are written as fixed-width
+// little-endian values: 1 byte for 8-bit types, 2 for 16-bit, 4 for 32-bit,
+// and 8 for all the others (including int, uint, and uintptr, so that the
+// format is the same on all architectures). String elements are written
+// as their length in bytes as a uvarint followed by their bytes.
+// See also [SortedSet.ReadFrom].
+func (me *SortedSet[E]) WriteTo(w io.Writer) (int64, error) {
+	const chunkSize = 1 << 16
+	var total int64
+	data := binary.AppendUvarint(make([]byte, 0, chunkSize),
+		uint64(me.Len()))
+	for element := range me.All() {
+		data = appendElement(data, element)
+		if len(data) >= chunkSize {
+			n, err := w.Write(data)
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+			data = data[:0]
+		}
+	}
+	n, err := w.Write(data)
+	return total + int64(n), err
+}
+
+// ReadFrom replaces the SortedSet's elements with those read from r which
+// must be in the format written by [SortedSet.WriteTo], and returns the
+// number of bytes read. If r isn't an [io.ByteReader] it is buffered, so
+// more bytes than are returned may be read from it. If an error occurs
+// the SortedSet is left unchanged.
+func (me *SortedSet[E]) ReadFrom(r io.Reader) (int64, error) {
+	reader, ok := r.(byteReader)
+	if !ok {
+		reader = bufio.NewReader(r)
+	}
+	counter := &countingReader{reader: reader}
+	count, err := binary.ReadUvarint(counter)
+	if err != nil {
+		return counter.count, unexpectedEOF(err)
+	}
+	elements := make([]E, 0, min(count, 1<<16))
+	for range count {
+		element, err := readElement[E](counter)
+		if err != nil {
+			return counter.count, unexpectedEOF(err)
+		}
+		elements = append(elements, element)
+	}
+	me.replace(FromSlice(elements))
+	return counter.count, nil
+}
+
+// MarshalBinary returns the SortedSet's elements in the compact binary
+// format described in [SortedSet.WriteTo], so the SortedSet implements
+// [encoding.BinaryMarshaler].
+// See also [SortedSet.UnmarshalBinary].
+func (me SortedSet[E]) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(nil, uint64(me.Len()))
+	for element := range me.All() {
+		data = appendElement(data, element)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces the SortedSet's elements with those in data
+// which must be in the format produced by [SortedSet.MarshalBinary] (or
+// [SortedSet.WriteTo]), so the SortedSet implements
+// [encoding.BinaryUnmarshaler]. If an error occurs (including if data has
+// trailing bytes) the SortedSet is left unchanged.
+func (me *SortedSet[E]) UnmarshalBinary(data []byte) error {
+	var sset SortedSet[E]
+	n, err := sset.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if int(n) != len(data) {
+		return fmt.Errorf("sortedset: %d unexpected trailing bytes",
+			len(data)-int(n))
+	}
+	me.replace(sset)
+	return nil
+}
+
+// Hash returns a 64-bit digest of the SortedSet's elements, so SortedSets
+// with the same elements always have the same Hash no matter how they were
+// built. The digest is the 64-bit FNV-1a hash (see [fnv.New64a]) of the
+// SortedSet's binary format (see [SortedSet.WriteTo]), so it is the same
+// on all architectures and will not change in future versions. Like any
+// hash, different SortedSets may (rarely) have the same Hash. For example:
+//
+//	key := sset.Hash()
+func (me *SortedSet[E]) Hash() uint64 {
+	hash := fnv.New64a()
+	data := binary.AppendUvarint(make([]byte, 0, 64), uint64(me.Len()))
+	for element := range me.All() {
+		data = appendElement(data, element)
+		if len(data) >= 64 {
+			hash.Write(data)
+			data = data[:0]
+		}
+	}
+	hash.Write(data)
+	return hash.Sum64()
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+type countingReader struct {
+	reader byteReader
+	count  int64
+}
+
+func (me *countingReader) Read(data []byte) (int, error) {
+	n, err := io.ReadFull(me.reader, data)
+	me.count += int64(n)
+	return n, err
+}
+
+func (me *countingReader) ReadByte() (byte, error) {
+	b, err := me.reader.ReadByte()
+	if err == nil {
+		me.count++
+	}
+	return b, err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// appendElement appends the binary encoding of the given element to data
+// as described in [SortedSet.WriteTo].
+func appendElement[E Comparable](data []byte, element E) []byte {
+	value := reflect.ValueOf(element)
+	switch value.Kind() {
+	case reflect.String:
+		data = binary.AppendUvarint(data, uint64(value.Len()))
+		return append(data, value.String()...)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return appendFixed(data, uint64(value.Int()), widthOf(value.Kind()))
+	default:
+		return appendFixed(data, value.Uint(), widthOf(value.Kind()))
+	}
+}
+
+func appendFixed(data []byte, u uint64, width int) []byte {
+	for range width {
+		data = append(data, byte(u))
+		u >>= 8
+	}
+	return data
+}
+
+// readElement reads one element in the binary encoding described in
+// [SortedSet.WriteTo].
+func readElement[E Comparable](reader byteReader) (E, error) {
+	var element E
+	value := reflect.ValueOf(&element).Elem()
+	if value.Kind() == reflect.String {
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return element, err
+		}
+		var text strings.Builder
+		if _, err = io.CopyN(&text, reader, int64(size)); err != nil {
+			return element, err
+		}
+		value.SetString(text.String())
+		return element, nil
+	}
+	width := widthOf(value.Kind())
+	var data [8]byte
+	if _, err := io.ReadFull(reader, data[:width]); err != nil {
+		return element, err
+	}
+	u := binary.LittleEndian.Uint64(data[:])
+	if value.CanInt() {
+		shift := 64 - 8*width // sign-extend
+		value.SetInt(int64(u<<shift) >> shift)
+	} else {
+		value.SetUint(u)
+	}
+	return element, nil
+}
+
+// widthOf returns the number of bytes used to encode an integer of the
+// given kind.
+func widthOf(kind reflect.Kind) int {
+	switch kind {
+	case reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int32, reflect.Uint32:
+		return 4
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	s := New(19, 21, 1, 2, 4, 8)
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(data), "[1,2,4,8,19,21]"; actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	u := New(99)
+	if err = json.Unmarshal(data, &u); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(u) {
+		t.Errorf("%v != %v", s, u)
+	}
+	if err = json.Unmarshal([]byte("[]"), &u); err != nil {
+		t.Fatal(err)
+	}
+	if !u.IsEmpty() {
+		t.Errorf("expected empty set, got %v", u)
+	}
+	w := New("one", "two")
+	data, err = json.Marshal(struct{ Words SortedSet[string] }{w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := string(data),
+		`{"Words":["one","two"]}`; actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	for _, text := range []string{`{"a":1}`, `7`, `"x"`, `["x"]`} {
+		u = New(5)
+		if err = json.Unmarshal([]byte(text), &u); err == nil {
+			t.Errorf("expected error for %s", text)
+		}
+		check(u.String(), u.Len(), "{5}", 1, t)
+	}
+	u = New(5)
+	if err = json.Unmarshal([]byte("null"), &u); err != nil {
+		t.Fatal(err)
+	}
+	check(u.String(), u.Len(), "{5}", 1, t) // null does nothing
+	holder := struct{ Words SortedSet[string] }{New("keep")}
+	if err = json.Unmarshal([]byte(`{"Words":null}`), &holder); err != nil {
+		t.Fatal(err)
+	}
+	check(holder.Words.String(), holder.Words.Len(), `{"keep"}`, 1, t)
+	if err = json.Unmarshal([]byte("[3,1,3,2]"), &u); err != nil {
+		t.Fatal(err)
+	}
+	checkLenAndOrder(&u, 3, t)
+	check(u.String(), u.Len(), "{1 2 3}", 3, t)
+}
+
+func TestJSONDeterministic(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	elements := rng.Perm(1000)
+	expected, err := json.Marshal(FromSlice(elements))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		var s SortedSet[int]
+		for _, i := range rng.Perm(len(elements)) {
+			s.Add(elements[i])
+			s.Add(-1 - rng.Intn(10)) // noise that is deleted below
+		}
+		for i := range 10 {
+			s.Delete(-1 - i)
+		}
+		u := s.Snapshot()
+		u.Add(1000)
+		u.Delete(1000)
+		for _, sset := range []SortedSet[int]{s, u} {
+			data, err := json.Marshal(sset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, expected) {
+				t.Fatalf("expected identical output, got %s", data)
+			}
+		}
+	}
+	if data, err := json.Marshal(New[string]()); err != nil ||
+		string(data) != "[]" {
+		t.Errorf("expected [], got %s: %v", data, err)
+	}
+}
+
+func TestGob(t *testing.T) {
+	type message struct {
+		Name  string
+		Words SortedSet[string]
+		Ints  SortedSet[int]
+	}
+	out := message{"test", New("one", "two", "three"), New[int]()}
+	for i := range 1000 {
+		out.Ints.Add(i * 3)
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(out); err != nil {
+		t.Fatal(err)
+	}
+	var in message
+	if err := gob.NewDecoder(&buffer).Decode(&in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Name != out.Name {
+		t.Errorf("expected %q, got %q", out.Name, in.Name)
+	}
+	if !in.Words.Equal(out.Words) {
+		t.Errorf("%v != %v", in.Words, out.Words)
+	}
+	if !in.Ints.Equal(out.Ints) {
+		t.Errorf("expected %d ints, got %d", out.Ints.Len(), in.Ints.Len())
+	}
+}
+
+func TestText(t *testing.T) {
+	s := New(19, 21, 1, -2, 4, 8)
+	text, err := s.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(string(text), s.Len(), "{-2 1 4 8 19 21}", 6, t)
+	var u SortedSet[int]
+	if err = u.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(u) {
+		t.Errorf("%v != %v", s, u)
+	}
+	w := New("one", "two words", `"quoted"`)
+	if text, err = w.MarshalText(); err != nil {
+		t.Fatal(err)
+	}
+	var x SortedSet[string]
+	if err = x.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Equal(x) {
+		t.Errorf("%v != %v", w, x)
+	}
+	if err = x.UnmarshalText([]byte(" { a  b\tc } ")); err != nil {
+		t.Fatal(err)
+	}
+	check(x.String(), x.Len(), `{"a" "b" "c"}`, 3, t)
+	if err = x.UnmarshalText([]byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	check(x.String(), x.Len(), "{}", 0, t)
+	for _, text := range []string{"1 2", "{1 x}", `{"1"}`, "{1 2", "{1,2}",
+		"{1 2x 3}", "{1.5}", "{0x10}"} {
+		u = New(5)
+		if err = u.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("expected error for %q", text)
+		}
+		check(u.String(), u.Len(), "{5}", 1, t)
+	}
+}
+
+func TestTextRange(t *testing.T) {
+	var small SortedSet[int8]
+	if err := small.UnmarshalText([]byte("{-128 127}")); err != nil {
+		t.Fatal(err)
+	}
+	check(small.String(), small.Len(), "{-128 127}", 2, t)
+	for _, text := range []string{"{128}", "{-129}"} {
+		if err := small.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("expected error for %q", text)
+		}
+	}
+	var unsigned SortedSet[uint16]
+	if err := unsigned.UnmarshalText([]byte("{-1}")); err == nil {
+		t.Error("expected error for {-1}")
+	}
+	if err := unsigned.UnmarshalText([]byte("{7 65535}")); err != nil {
+		t.Fatal(err)
+	}
+	check(unsigned.String(), unsigned.Len(), "{7 65535}", 2, t)
+	check(small.String(), small.Len(), "{-128 127}", 2, t)
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	s := New(math.MinInt64, -1, 0, 1, 255, 256, math.MaxInt64)
+	var buffer bytes.Buffer
+	n, err := s.WriteTo(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1+7*8 || int(n) != buffer.Len() {
+		t.Errorf("expected %d bytes, got %d (%d)", 1+7*8, n, buffer.Len())
+	}
+	buffer.WriteString("trailing")
+	u := New(99)
+	if m, err := u.ReadFrom(&buffer); err != nil || m != n {
+		t.Fatalf("expected %d bytes, got %d: %v", n, m, err)
+	}
+	if !s.Equal(u) || !u.IsValid() {
+		t.Errorf("%v != %v", s, u)
+	}
+	if buffer.String() != "trailing" {
+		t.Errorf("unexpected bytes consumed: %q", buffer.String())
+	}
+	type ID int8
+	ids := New[ID](-128, -1, 0, 127)
+	buffer.Reset()
+	if n, err = ids.WriteTo(&buffer); err != nil || n != 5 {
+		t.Fatalf("expected 5 bytes, got %d: %v", n, err)
+	}
+	var x SortedSet[ID]
+	if _, err = x.ReadFrom(io.MultiReader(&buffer)); err != nil {
+		t.Fatal(err)
+	}
+	check(x.String(), x.Len(), ids.String(), ids.Len(), t)
+	words := New("", "one", "two words", "ünïcödé", "a much longer string")
+	for range 10000 {
+		words.Add(fmt.Sprint(words.Len()))
+	}
+	buffer.Reset()
+	if _, err = words.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	var w SortedSet[string]
+	if _, err = w.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !words.Equal(w) {
+		t.Errorf("expected %d words, got %d", words.Len(), w.Len())
+	}
+	u8 := New[uint8](0, 1, 255)
+	buffer.Reset()
+	if _, err = u8.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+	var v SortedSet[uint8]
+	if _, err = v.ReadFrom(bytes.NewReader(data[:len(data)-1])); err !=
+		io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	if _, err = v.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	check(v.String(), v.Len(), "{0 1 255}", 3, t)
+}
+
+func TestBinary(t *testing.T) {
+	var _ encoding.BinaryMarshaler = SortedSet[int]{}
+	var _ encoding.BinaryUnmarshaler = &SortedSet[int]{}
+	s := New("one", "two", "three", "")
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if _, err = s.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, buffer.Bytes()) {
+		t.Errorf("expected %v, got %v", buffer.Bytes(), data)
+	}
+	u := New("x")
+	if err = u.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	check(u.String(), u.Len(), s.String(), s.Len(), t)
+	if err = u.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("expected trailing bytes error")
+	}
+	if err = u.UnmarshalBinary(data[:len(data)-1]); err !=
+		io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	check(u.String(), u.Len(), s.String(), s.Len(), t) // unchanged
+	var empty SortedSet[int16]
+	if data, err = empty.MarshalBinary(); err != nil || len(data) != 1 {
+		t.Fatalf("expected 1 byte, got %d: %v", len(data), err)
+	}
+	v := New[int16](1, 2)
+	if err = v.UnmarshalBinary(data); err != nil || !v.IsEmpty() {
+		t.Errorf("expected empty set, got %v: %v", v, err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	var empty SortedSet[int]
+	s := New(1, 2, 3)
+	u := New("a", "bc")
+	for _, datum := range []struct {
+		actual, expected uint64
+	}{
+		{empty.Hash(), 0xaf63bd4c8601b7df},
+		{s.Hash(), 0x94dab58ce357c852},
+		{u.Hash(), 0x124aac5e29cda33c},
+	} {
+		if datum.actual != datum.expected {
+			t.Errorf("expected %#x, got %#x", datum.expected, datum.actual)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	var a, b SortedSet[uint16]
+	for _, x := range rng.Perm(1000) {
+		a.Add(uint16(x))
+	}
+	for x := range 1000 {
+		b.Add(uint16(x))
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf("expected equal hashes, got %#x %#x", a.Hash(), b.Hash())
+	}
+	b.Delete(500)
+	if a.Hash() == b.Hash() {
+		t.Errorf("expected different hashes, got %#x", a.Hash())
+	}
+	v := New("ab", "c")
+	if u.Hash() == v.Hash() {
+		t.Errorf("expected different hashes, got %#x", u.Hash())
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// SortedMultiset is a sorted collection of elements that counts duplicates
+// rather than collapsing them, e.g., a sorted histogram. Internally it is
+// a SortedSet of the distinct elements plus a map of their counts (so
+// every element present has a count of at least 1).
+//
+// SortedMultiset's zero value is usable. For example:
+//
+//	var words SortedMultiset[string]
+//	for _, word := range strings.Fields(text) {
+//		words.Add(word)
+//	}
+//	for word, count := range words.All() {
+//		fmt.Println(word, count)
+//	}
+type SortedMultiset[E Comparable] struct {
+	sset   SortedSet[E]
+	counts map[E]int
+	total  int
+}
+
+// NewMultiset returns a new SortedMultiset that contains the given
+// elements (if any), with each duplicate counted.
+func NewMultiset[E Comparable](elements ...E) SortedMultiset[E] {
+	var multiset SortedMultiset[E]
+	for _, element := range elements {
+		multiset.Add(element)
+	}
+	return multiset
+}
+
+// Add adds one occurrence of the element into the SortedMultiset and
+// returns the element's new count.
+func (me *SortedMultiset[E]) Add(element E) int {
+	return me.AddN(element, 1)
+}
+
+// AddN adds n occurrences of the element into the SortedMultiset and
+// returns the element's new count. If n <= 0 nothing is added and the
+// element's current count is returned.
+func (me *SortedMultiset[E]) AddN(element E, n int) int {
+	if n <= 0 {
+		return me.counts[element]
+	}
+	if me.counts == nil {
+		me.counts = make(map[E]int)
+	}
+	if me.counts[element] == 0 {
+		me.sset.Add(element)
+	}
+	me.counts[element] += n
+	me.total += n
+	return me.counts[element]
+}
+
+// Delete deletes one occurrence of the element from the SortedMultiset and
+// returns true, deleting the element entirely if its count falls to 0; or
+// does nothing and returns false if the element isn't present.
+func (me *SortedMultiset[E]) Delete(element E) bool {
+	count := me.counts[element]
+	if count == 0 {
+		return false
+	}
+	if count == 1 {
+		delete(me.counts, element)
+		me.sset.Delete(element)
+	} else {
+		me.counts[element] = count - 1
+	}
+	me.total--
+	return true
+}
+
+// DeleteAll deletes every occurrence of the element from the
+// SortedMultiset and returns how many there were (which is 0 if the
+// element isn't present).
+func (me *SortedMultiset[E]) DeleteAll(element E) int {
+	count := me.counts[element]
+	if count > 0 {
+		delete(me.counts, element)
+		me.sset.Delete(element)
+		me.total -= count
+	}
+	return count
+}
+
+// Count returns the number of occurrences of x in the SortedMultiset,
+// which is 0 if x isn't present.
+func (me *SortedMultiset[E]) Count(x E) int { return me.counts[x] }
+
+// Contains returns true if the element is in the SortedMultiset; otherwise
+// false.
+func (me *SortedMultiset[E]) Contains(element E) bool {
+	return me.counts[element] > 0
+}
+
+// Len returns the number of distinct elements in the SortedMultiset.
+// See also [SortedMultiset.Total].
+func (me *SortedMultiset[E]) Len() int { return me.sset.Len() }
+
+// Total returns the number of elements in the SortedMultiset counting
+// every occurrence, i.e., the sum of all the counts.
+func (me *SortedMultiset[E]) Total() int { return me.total }
+
+// IsEmpty returns true if there are no elements in the SortedMultiset;
+// otherwise returns false.
+func (me *SortedMultiset[E]) IsEmpty() bool { return me.sset.IsEmpty() }
+
+// Min returns the SortedMultiset's smallest element and its count; or the
+// zero value and 0 if the SortedMultiset is empty.
+func (me *SortedMultiset[E]) Min() (E, int) {
+	element, _ := me.sset.Min()
+	return element, me.counts[element]
+}
+
+// Max returns the SortedMultiset's largest element and its count; or the
+// zero value and 0 if the SortedMultiset is empty.
+func (me *SortedMultiset[E]) Max() (E, int) {
+	element, _ := me.sset.Max()
+	return element, me.counts[element]
+}
+
+// All returns a for .. range iterable of the SortedMultiset's distinct
+// elements in ascending order, each with its count, e.g.,
+// for element, count := range multiset.All()
+func (me *SortedMultiset[E]) All() iter.Seq2[E, int] {
+	return func(yield func(E, int) bool) {
+		for element := range me.sset.All() {
+			if !yield(element, me.counts[element]) {
+				return
+			}
+		}
+	}
+}
+
+// Elements returns a for .. range iterable of the SortedMultiset's
+// distinct elements in ascending order, e.g.,
+// for element := range multiset.Elements()
+func (me *SortedMultiset[E]) Elements() iter.Seq[E] { return me.sset.All() }
+
+// Clear deletes all the elements in the SortedMultiset.
+func (me *SortedMultiset[E]) Clear() {
+	me.sset.Clear()
+	me.counts = nil
+	me.total = 0
+}
+
+// ToSlice returns the SortedMultiset's elements as a sorted slice, with
+// each element repeated as many times as its count.
+func (me *SortedMultiset[E]) ToSlice() []E {
+	slice := make([]E, 0, me.total)
+	for element, count := range me.All() {
+		for range count {
+			slice = append(slice, element)
+		}
+	}
+	return slice
+}
+
+// String returns a human readable string representation of the
+// SortedMultiset with each element followed by a colon and its count,
+// e.g., {1:2 5:1}.
+func (me *SortedMultiset[E]) String() string {
+	format := "%s%v:%d"
+	if isString[E]() {
+		format = "%s%q:%d"
+	}
+	var out strings.Builder
+	out.WriteByte('{')
+	sep := ""
+	for element, count := range me.All() {
+		fmt.Fprintf(&out, format, sep, element, count)
+		sep = " "
+	}
+	out.WriteByte('}')
+	return out.String()
+}
@@ -0,0 +1,49 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultiset(t *testing.T) {
+	var m SortedMultiset[string]
+	check(m.String(), m.Len(), "{}", 0, t)
+	if x, count := m.Min(); x != "" || count != 0 {
+		t.Errorf("expected \"\" 0, got %q %d", x, count)
+	}
+	for _, word := range []string{"b", "a", "b", "c", "b", "a"} {
+		m.Add(word)
+	}
+	check(m.String(), m.Len(), `{"a":2 "b":3 "c":1}`, 3, t)
+	if m.Total() != 6 || m.Count("b") != 3 || m.Count("z") != 0 {
+		t.Errorf("expected 6 3 0, got %d %d %d", m.Total(), m.Count("b"),
+			m.Count("z"))
+	}
+	if count := m.AddN("d", 4); count != 4 || m.AddN("d", 0) != 4 {
+		t.Errorf("expected 4, got %d", count)
+	}
+	if x, count := m.Max(); x != "d" || count != 4 {
+		t.Errorf("expected \"d\" 4, got %q %d", x, count)
+	}
+	if !m.Delete("a") || m.Count("a") != 1 || !m.Delete("a") ||
+		m.Contains("a") || m.Delete("a") {
+		t.Errorf("unexpected Delete results: %v", &m)
+	}
+	if count := m.DeleteAll("d"); count != 4 || m.DeleteAll("d") != 0 {
+		t.Errorf("expected 4, got %d", count)
+	}
+	check(m.String(), m.Len(), `{"b":3 "c":1}`, 2, t)
+	if actual := m.ToSlice(); !slices.Equal(actual,
+		[]string{"b", "b", "b", "c"}) || m.Total() != 4 {
+		t.Errorf("expected [b b b c] 4, got %v %d", actual, m.Total())
+	}
+	m.Clear()
+	check(m.String(), m.Len(), "{}", 0, t)
+	n := NewMultiset(3, 1, 3, 3)
+	check(n.String(), n.Len(), "{1:1 3:3}", 2, t)
+	if actual := slices.Collect(n.Elements()); !slices.Equal(actual,
+		[]int{1, 3}) {
+		t.Errorf("expected [1 3], got %v", actual)
+	}
+}
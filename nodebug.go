@@ -0,0 +1,7 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+//go:build !sortedset_debug
+
+package sortedset
+
+// debug is false unless built with -tags sortedset_debug; see debug.go.
+const debug = false
@@ -9,14 +9,30 @@ package sortedset
 
 import (
 	"fmt"
+	"io"
 	"iter"
+	"maps"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strconv"
 	"strings"
+	"unsafe"
 
 	"github.com/mark-summerfield/unum"
 )
 
+// Comparable allows only string or integer set elements. Floating-point
+// types are deliberately excluded because NaN isn't ordered by < (neither
+// NaN < x nor x < NaN is ever true), which would break the tree's
+// ordering. For a set of floats use [NewFunc] with [cmp.Less], which
+// orders NaN before all other values, e.g.,
+// NewFunc(cmp.Less[float64], 1.5, math.NaN()).
 type Comparable = unum.Comparable
 
+// Integer allows only integer set elements (see [CoversRange]).
+type Integer = unum.Integer
+
 // SortedSet zero value is usable. Create with statements like these:
 //
 //	var set SortedSet[string]
@@ -25,9 +41,29 @@ type Comparable = unum.Comparable
 // or use [New]:
 //
 //	set := New(1, 2, 4)
+//
+// Copying a SortedSet (e.g., b := a) is cheap because the copy shares the
+// original's tree, but this means that the copy is only valid until one or
+// the other is changed. For an independent copy use [SortedSet.Snapshot]
+// (which is also cheap) or [SortedSet.Clone].
 type SortedSet[E Comparable] struct {
-	root *node[E]
-	size int
+	root  *node[E]
+	size  int
+	alloc *allocator[E] // spare nodes (see NewWithCapacity)
+	owner bool          // true if this SortedSet may use alloc's nodes
+	cow   *cowToken     // identifies the nodes this SortedSet may mutate
+	mods  int           // incremented by every change (see checkMods)
+}
+
+// allocator holds the spare nodes that a SortedSet takes from before
+// allocating new ones. Only the first SortedSet to use them becomes their
+// owner, so a copy made before then (e.g., b := a) never takes any of
+// them. Since ownership is recorded in the SortedSet itself it moves with
+// it, e.g., when it is returned from a function or stored in a struct.
+type allocator[E Comparable] struct {
+	claimed bool      // true once a SortedSet has become the owner
+	slab    []node[E] // preallocated nodes (see NewWithCapacity)
+	free    *node[E]  // recycled nodes linked by left (see ClearRetaining)
 }
 
 // New returns a new SortedSet containing the given elements (if any).
@@ -41,10 +77,107 @@ func New[E Comparable](elements ...E) SortedSet[E] {
 	return sset
 }
 
-type node[E Comparable] struct {
+// NewWithCapacity returns a new empty SortedSet with space preallocated for
+// capacity elements. This means that adding up to capacity elements needs
+// no further allocations rather than one per element. Note,
+// though, that none of the preallocated space is freed until the SortedSet
+// itself is freed. The preallocated space belongs to whichever SortedSet
+// first adds an element, so if the returned SortedSet is copied before
+// then, only one copy gets the space.
+func NewWithCapacity[E Comparable](capacity int) SortedSet[E] {
+	return SortedSet[E]{alloc: &allocator[E]{slab: make([]node[E],
+		capacity)}}
+}
+
+// FromSlice returns a new SortedSet containing the given elements (if
+// any). This is much faster than adding the elements one at a time (e.g.,
+// using [New]) since the tree is built directly in balanced form.
+func FromSlice[E Comparable](elements []E) SortedSet[E] {
+	elements = slices.Clone(elements)
+	slices.Sort(elements)
+	return fromSorted(slices.Compact(elements))
+}
+
+// Collect returns a new SortedSet containing the elements yielded by the
+// given iterator. For example:
+//
+//	keys := Collect(maps.Keys(m))
+func Collect[E Comparable](seq iter.Seq[E]) SortedSet[E] {
+	elements := slices.Sorted(seq)
+	return fromSorted(slices.Compact(elements))
+}
+
+// fromSorted returns a new SortedSet containing the given elements which
+// must be in ascending order with no duplicates.
+func fromSorted[E Comparable](elements []E) SortedSet[E] {
+	blackHeight := 0
+	for (2<<blackHeight)-1 <= len(elements) {
+		blackHeight++
+	}
+	return SortedSet[E]{root: build(elements, blackHeight),
+		size: len(elements)}
+}
+
+// build returns a tree of the given elements (which must be in ascending
+// order with no duplicates) with the given black height, i.e., a 2-3 tree
+// of that height. The number of elements must be at least 2^blackHeight -
+// 1 (all 2-nodes) and at most 3^blackHeight - 1 (all 3-nodes).
+func build[E any](elements []E, blackHeight int) *node[E] {
+	size := len(elements)
+	if size == 0 {
+		return nil
+	}
+	blackHeight--
+	maxChildSize := 1
+	for range blackHeight {
+		maxChildSize *= 3
+	}
+	maxChildSize--
+	if size-1 <= 2*maxChildSize { // 2-node
+		mid := size / 2
+		root := &node[E]{element: elements[mid], size: size}
+		root.left = build(elements[:mid], blackHeight)
+		root.right = build(elements[mid+1:], blackHeight)
+		return root
+	}
+	// 3-node: a black node with a red left child
+	third := (size - 2) / 3
+	i := third + (size-2)%3/2 // spread any remainder across the ends
+	j := size - third - 1
+	left := &node[E]{element: elements[i], red: true, size: j}
+	left.left = build(elements[:i], blackHeight)
+	left.right = build(elements[i+1:j], blackHeight)
+	root := &node[E]{element: elements[j], left: left, size: size}
+	root.right = build(elements[j+1:], blackHeight)
+	return root
+}
+
+// maxHeight is the maximum possible height of a red-black tree (since the
+// height is at most 2 log₂(n + 1) and n must fit in an int).
+const maxHeight = 128
+
+type node[E any] struct {
 	element     E
 	red         bool
+	size        int // number of nodes in this subtree (for order statistics)
 	left, right *node[E]
+	cow         *cowToken // the owner's token when the node was created
+}
+
+// cowToken identifies the owner of a node for copy-on-write. It must not be
+// zero-sized since distinct zero-sized values may share the same address.
+type cowToken struct{ _ byte }
+
+// mutable returns root if it belongs to cow and so may be changed in
+// place; otherwise returns a copy of root that belongs to cow. This means
+// that nodes shared with a snapshot are copied before being changed.
+func mutable[E any](cow *cowToken, root *node[E]) *node[E] {
+	if root == nil || root.cow == cow {
+		return root
+	}
+	clone := *root
+	clone.cow = cow
+	return &clone
 }
 
 // Add adds a new element into the SortedSet and returns true; or does
@@ -53,86 +186,377 @@ type node[E Comparable] struct {
 //
 //	ok := sset.Add(element).
 func (me *SortedSet[E]) Add(element E) bool {
-	inserted := false
-	me.root, inserted = me.insert(me.root, element)
+	_, _, added := me.add(element)
+	return added
+}
+
+// AddAndNeighbors adds x into the SortedSet if it isn't already present,
+// and returns the element before x (if any) and the element after x (if
+// any) in a single descent of the tree. For example:
+//
+//	prev, hasPrev, next, hasNext := sset.AddAndNeighbors(x)
+//
+// See also [SortedSet.Lower] and [SortedSet.Higher].
+func (me *SortedSet[E]) AddAndNeighbors(x E) (prev E, hasPrev bool, next E,
+	hasNext bool,
+) {
+	lower, higher, _ := me.add(x)
+	if lower != nil {
+		prev, hasPrev = lower.element, true
+	}
+	if higher != nil {
+		next, hasNext = higher.element, true
+	}
+	return prev, hasPrev, next, hasNext
+}
+
+// add adds the element if it isn't already present and returns true; or
+// returns false. It also returns the nodes (if any) holding the elements
+// immediately before and after the element.
+func (me *SortedSet[E]) add(element E) (lower, higher *node[E], added bool) {
+	var path [maxHeight]*node[E]
+	depth := 0
+	root := me.root
+	for root != nil {
+		if element < root.element {
+			path[depth], higher = root, root
+			root = root.left
+		} else if root.element < element {
+			path[depth], lower = root, root
+			root = root.right
+		} else {
+			if root.left != nil {
+				lower = last(root.left)
+			}
+			if root.right != nil {
+				higher = first(root.right)
+			}
+			return lower, higher, false
+		}
+		depth++
+	}
+	// If element was in the SortedSet it would go here
+	root = me.newNode(element)
+	for depth > 0 { // Rebalance on the way back up
+		depth--
+		parent := mutable(me.cow, path[depth])
+		if element < parent.element {
+			parent.left = root
+		} else {
+			parent.right = root
+		}
+		resize(parent)
+		root = insertRotation(me.cow, parent)
+	}
+	me.root = root
 	me.root.red = false
-	if inserted {
-		me.size++
+	me.size++
+	me.mods++
+	return lower, higher, true
+}
+
+// Replace adds x into the SortedSet and returns the zero value and false
+// if x wasn't already present; otherwise replaces the stored element that
+// is equal to x with x and returns the old stored element and true. Since
+// equal elements are identical for the types a SortedSet can hold, this is
+// mostly useful for [SortedSetFunc.Replace]'s sake, but it does allow
+// replacing a particular stored instance (e.g., of an interned string).
+// See also [SortedSet.Add] and [SortedSet.Get].
+func (me *SortedSet[E]) Replace(x E) (E, bool) {
+	var path [maxHeight]*node[E]
+	depth := 0
+	root := me.root
+	for root != nil && root.element != x {
+		path[depth] = root
+		depth++
+		if x < root.element {
+			root = root.left
+		} else {
+			root = root.right
+		}
+	}
+	if root == nil {
+		me.Add(x)
+		var zero E
+		return zero, false
 	}
-	return inserted
+	old := root.element
+	root = mutable(me.cow, root)
+	root.element = x
+	for depth > 0 { // Copy any shared nodes on the way back up
+		depth--
+		parent := mutable(me.cow, path[depth])
+		if x < parent.element {
+			parent.left = root
+		} else {
+			parent.right = root
+		}
+		root = parent
+	}
+	me.root = root
+	return old, true
 }
 
-func (me *SortedSet[E]) insert(root *node[E], element E) (*node[E], bool) {
-	inserted := false
-	if root == nil { // If element was in the SortedSet it would go here
-		return &node[E]{element: element, red: true}, true
+// AddAll adds each of the given elements that isn't already present into
+// the SortedSet and returns how many were added. For example:
+//
+//	count := sset.AddAll(element1, element2, element3).
+//
+// See also [SortedSet.Add] and [SortedSet.DeleteAll].
+func (me *SortedSet[E]) AddAll(elements ...E) int {
+	count := 0
+	for _, element := range elements {
+		if me.Add(element) {
+			count++
+		}
 	}
-	if isRed(root.left) && isRed(root.right) {
-		colorFlip(root)
+	return count
+}
+
+// newNode returns a new red node for the given element, taken from the
+// recycled nodes or the slab if there are any available.
+func (me *SortedSet[E]) newNode(element E) *node[E] {
+	if alloc := me.allocator(); alloc != nil {
+		if root := alloc.free; root != nil {
+			alloc.free = root.left
+			*root = node[E]{element: element, red: true, size: 1,
+				cow: me.cow}
+			return root
+		}
+		if len(alloc.slab) > 0 {
+			root := &alloc.slab[0]
+			alloc.slab = alloc.slab[1:]
+			root.element, root.red, root.size, root.cow = element, true, 1,
+				me.cow
+			return root
+		}
+	}
+	return &node[E]{element: element, red: true, size: 1, cow: me.cow}
+}
+
+// allocator returns this SortedSet's allocator, claiming it if it has no
+// owner yet; or nil if there isn't one or it belongs to another SortedSet.
+func (me *SortedSet[E]) allocator() *allocator[E] {
+	if me.alloc == nil {
+		return nil
 	}
-	if element < root.element {
-		root.left, inserted = me.insert(root.left, element)
-	} else if root.element < element {
-		root.right, inserted = me.insert(root.right, element)
+	if !me.alloc.claimed {
+		me.alloc.claimed, me.owner = true, true
 	}
-	root = insertRotation(root)
-	return root, inserted
+	if !me.owner {
+		return nil
+	}
+	return me.alloc
 }
 
-func isRed[E Comparable](root *node[E]) bool {
+func isRed[E any](root *node[E]) bool {
 	return root != nil && root.red
 }
 
-func colorFlip[E Comparable](root *node[E]) {
+func sizeOf[E any](root *node[E]) int {
+	if root == nil {
+		return 0
+	}
+	return root.size
+}
+
+// resize must be called whenever a node's children change.
+func resize[E any](root *node[E]) {
+	root.size = 1 + sizeOf(root.left) + sizeOf(root.right)
+}
+
+func colorFlip[E any](cow *cowToken, root *node[E]) {
 	root.red = !root.red
 	if root.left != nil {
+		root.left = mutable(cow, root.left)
 		root.left.red = !root.left.red
 	}
 	if root.right != nil {
+		root.right = mutable(cow, root.right)
 		root.right.red = !root.right.red
 	}
 }
 
-func insertRotation[E Comparable](root *node[E]) *node[E] {
+func insertRotation[E any](cow *cowToken, root *node[E]) *node[E] {
 	if isRed(root.right) && !isRed(root.left) {
-		root = rotateLeft(root)
+		root = rotateLeft(cow, root)
 	}
 	if isRed(root.left) && isRed(root.left.left) {
-		root = rotateRight(root)
+		root = rotateRight(cow, root)
+	}
+	// 4-nodes are split on the way up (rather than on the way down) so
+	// that the tree is always a 2-3 tree, as the delete algorithm expects.
+	if isRed(root.left) && isRed(root.right) {
+		colorFlip(cow, root)
 	}
 	return root
 }
 
-func rotateLeft[E Comparable](root *node[E]) *node[E] {
-	x := root.right
+func rotateLeft[E any](cow *cowToken, root *node[E]) *node[E] {
+	x := mutable(cow, root.right)
 	root.right = x.left
 	x.left = root
 	x.red = root.red
 	root.red = true
+	x.size = root.size
+	resize(root)
 	return x
 }
 
-func rotateRight[E Comparable](root *node[E]) *node[E] {
-	x := root.left
+func rotateRight[E any](cow *cowToken, root *node[E]) *node[E] {
+	x := mutable(cow, root.left)
 	root.left = x.right
 	x.right = root
 	x.red = root.red
 	root.red = true
+	x.size = root.size
+	resize(root)
 	return x
 }
 
+// IsValid returns true if the SortedSet's underlying left-leaning
+// red-black tree is well-formed; otherwise returns false. A well-formed
+// tree has a black root, no right-leaning red links, no two consecutive
+// red links, the same number of black links on every path from the root to
+// a leaf, elements in ascending order, and correct subtree sizes.
+// This is intended for use in tests.
+func (me *SortedSet[E]) IsValid() bool {
+	if isRed(me.root) || sizeOf(me.root) != me.size {
+		return false
+	}
+	_, ok := isValid(me.root, nil, nil)
+	return ok
+}
+
+// isValid returns the black height of the given tree and true if it is
+// valid and all its elements are > lo (if not nil) and < hi (if not nil);
+// otherwise returns false.
+func isValid[E Comparable](root *node[E], lo, hi *E) (int, bool) {
+	if root == nil {
+		return 0, true
+	}
+	if isRed(root.right) || (root.red && isRed(root.left)) ||
+		(lo != nil && !(*lo < root.element)) ||
+		(hi != nil && !(root.element < *hi)) ||
+		root.size != 1+sizeOf(root.left)+sizeOf(root.right) {
+		return 0, false
+	}
+	leftHeight, ok := isValid(root.left, lo, &root.element)
+	if !ok {
+		return 0, false
+	}
+	rightHeight, ok := isValid(root.right, &root.element, hi)
+	if !ok || leftHeight != rightHeight {
+		return 0, false
+	}
+	if !root.red {
+		leftHeight++
+	}
+	return leftHeight, true
+}
+
+// Height returns the number of nodes on the longest path from the root of
+// the SortedSet's underlying red-black tree to a leaf, or 0 if the
+// SortedSet is empty. This is at most 2 log₂(Len() + 1).
+// See also [SortedSet.BlackHeight].
+func (me *SortedSet[E]) Height() int { return height(me.root) }
+
+func height[E any](root *node[E]) int {
+	if root == nil {
+		return 0
+	}
+	return 1 + max(height(root.left), height(root.right))
+}
+
+// BlackHeight returns the number of black nodes on every path from the root
+// of the SortedSet's underlying red-black tree to a leaf, or 0 if the
+// SortedSet is empty.
+// See also [SortedSet.Height].
+func (me *SortedSet[E]) BlackHeight() int { return blackHeight(me.root) }
+
+func blackHeight[E any](root *node[E]) int {
+	height := 0
+	for ; root != nil; root = root.left {
+		if !root.red {
+			height++
+		}
+	}
+	return height
+}
+
+// ToDOT returns a Graphviz DOT description of the SortedSet's underlying
+// red-black tree, with each node filled red or black, and with edges to
+// left and right children leaving from the bottom left and bottom right of
+// their parents. For example, this can be rendered as an SVG image using:
+// dot -Tsvg -o tree.svg tree.dot
+func (me *SortedSet[E]) ToDOT() string {
+	var out strings.Builder
+	out.WriteString("digraph SortedSet {\n")
+	out.WriteString("\tnode [style=filled, fontcolor=white];\n")
+	toDOT(&out, me.root, 0)
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// toDOT writes the DOT nodes and edges for the subtree whose root has the
+// given id (ids being assigned in pre-order) and returns the next id.
+func toDOT[E any](out *strings.Builder, root *node[E], id int) int {
+	if root == nil {
+		return id
+	}
+	color := "black"
+	if root.red {
+		color = "red"
+	}
+	fmt.Fprintf(out, "\tn%d [label=%s, fillcolor=%s];\n", id,
+		strconv.Quote(fmt.Sprint(root.element)), color)
+	next := id + 1
+	if root.left != nil {
+		fmt.Fprintf(out, "\tn%d:sw -> n%d;\n", id, next)
+		next = toDOT(out, root.left, next)
+	}
+	if root.right != nil {
+		fmt.Fprintf(out, "\tn%d:se -> n%d;\n", id, next)
+		next = toDOT(out, root.right, next)
+	}
+	return next
+}
+
 // Len returns the number of items in the SortedSet.
 func (me *SortedSet[E]) Len() int { return me.size }
 
+// MemStats returns the number of nodes in the SortedSet's tree (which is
+// always the same as [SortedSet.Len]) and an approximate number of bytes
+// used by the SortedSet. The approximation is the size of the SortedSet
+// itself plus the size of a node multiplied by the number of nodes,
+// including any unused nodes preallocated by [NewWithCapacity] or kept by
+// [SortedSet.ClearRetaining]. It excludes memory that the elements
+// themselves refer to (e.g., string elements' bytes), and any nodes shared
+// with snapshots are counted by every SortedSet that shares them.
+func (me *SortedSet[E]) MemStats() (nodes int, bytesApprox int) {
+	spare := 0
+	if alloc := me.alloc; alloc != nil && (me.owner || !alloc.claimed) {
+		spare = len(alloc.slab)
+		for root := alloc.free; root != nil; root = root.left {
+			spare++
+		}
+	}
+	nodeSize := int(unsafe.Sizeof(node[E]{}))
+	return me.size, int(unsafe.Sizeof(*me)) + (me.size+spare)*nodeSize
+}
+
 // All returns a for .. range iterable of the SortedSet's elements, e.g.,
 // for element := range sset.All()
+// Changing the SortedSet (e.g., by adding or deleting an element) inside
+// the loop causes a panic; to change it, either break out of the loop
+// first or iterate over a copy (e.g., one returned by [SortedSet.ToSlice]).
 func (me *SortedSet[E]) All() iter.Seq[E] {
 	return func(yield func(E) bool) {
-		all(me.root, yield)
+		all(me.root, me.checkMods(yield))
 	}
 }
 
-func all[E Comparable](root *node[E], yield func(E) bool) bool {
+func all[E any](root *node[E], yield func(E) bool) bool {
 	if root != nil {
 		return all(root.left, yield) &&
 			yield(root.element) &&
@@ -158,6 +582,147 @@ func (me *SortedSet[E]) AllX(start ...int) iter.Seq2[int, E] {
 	}
 }
 
+// Backward returns a for .. range iterable of the SortedSet's elements in
+// descending order, e.g.,
+// for element := range sset.Backward()
+// As with [SortedSet.All], changing the SortedSet inside the loop causes a
+// panic.
+func (me *SortedSet[E]) Backward() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		backward(me.root, me.checkMods(yield))
+	}
+}
+
+// checkMods returns a yield function that calls the given yield and then
+// panics if the SortedSet was changed (e.g., by [SortedSet.Add] or
+// [SortedSet.Delete]) while yield was running, since continuing to iterate
+// over a changed tree could silently skip or repeat elements.
+func (me *SortedSet[E]) checkMods(yield func(E) bool) func(E) bool {
+	mods := me.mods
+	return func(element E) bool {
+		if !yield(element) {
+			return false
+		}
+		if me.mods != mods {
+			panic("sortedset: concurrent modification during iteration")
+		}
+		return true
+	}
+}
+
+func backward[E any](root *node[E], yield func(E) bool) bool {
+	if root != nil {
+		return backward(root.right, yield) &&
+			yield(root.element) &&
+			backward(root.left, yield)
+	}
+	return true
+}
+
+// BackwardX returns an iterator of the SortedSet's elements in descending
+// order, e.g.,
+// for count, element := range sset.BackwardX(1) ...
+func (me *SortedSet[E]) BackwardX(start ...int) iter.Seq2[int, E] {
+	return func(yield func(int, E) bool) {
+		i := 0
+		if len(start) > 0 {
+			i = start[0]
+		}
+		for key := range me.Backward() {
+			if !yield(i, key) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Between returns a for .. range iterable of the SortedSet's elements that
+// are greater than or equal to lo and less than hi, e.g.,
+// for element := range sset.Between(lo, hi)
+// If lo > hi nothing is yielded. As with [SortedSet.All], changing the
+// SortedSet inside the loop causes a panic.
+func (me *SortedSet[E]) Between(lo, hi E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if lo < hi {
+			between(me.root, &lo, &hi, me.checkMods(yield))
+		}
+	}
+}
+
+// AllFrom returns a for .. range iterable of the SortedSet's elements that
+// are greater than or equal to start, e.g.,
+// for element := range sset.AllFrom(start)
+func (me *SortedSet[E]) AllFrom(start E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		between(me.root, &start, nil, me.checkMods(yield))
+	}
+}
+
+// Until returns a for .. range iterable of the SortedSet's elements that
+// are less than hi, e.g.,
+// for element := range sset.Until(hi)
+// The traversal stops as soon as hi is reached so elements that are
+// greater than or equal to hi are never visited. Until is a lazy
+// alternative to [SortedSet.HeadSet]; see also [SortedSet.AllFrom].
+func (me *SortedSet[E]) Until(hi E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		between(me.root, nil, &hi, me.checkMods(yield))
+	}
+}
+
+// fewEnough returns true if count operations that each take O(log size)
+// time (e.g., lookups or deletions) on a tree of size elements are cheaper
+// than visiting every element (e.g., to merge or rebuild); otherwise false.
+func fewEnough(count, size int) bool {
+	return count*bits.Len(uint(size)) < size
+}
+
+// between yields the elements that are >= lo (if lo isn't nil) and < hi
+// (if hi isn't nil).
+func between[E Comparable](root *node[E], lo, hi *E,
+	yield func(E) bool,
+) bool {
+	if root == nil {
+		return true
+	}
+	if lo != nil && root.element < *lo { // Root & left of root too small
+		return between(root.right, lo, hi, yield)
+	}
+	if hi != nil && !(root.element < *hi) { // Root & right of root too big
+		return between(root.left, lo, hi, yield)
+	}
+	return between(root.left, lo, hi, yield) &&
+		yield(root.element) &&
+		between(root.right, lo, hi, yield)
+}
+
+// Chunks returns an iterator of successive slices of up to size of the
+// SortedSet's elements in ascending order, e.g.,
+// for chunk := range sset.Chunks(100)
+// Every chunk except possibly the last has size elements. Each chunk is a
+// new slice which the caller may keep. Chunks panics if size < 1.
+func (me *SortedSet[E]) Chunks(size int) iter.Seq[[]E] {
+	if size < 1 {
+		panic("sortedset: Chunks requires a size of at least 1")
+	}
+	return func(yield func([]E) bool) {
+		chunk := make([]E, 0, min(size, me.Len()))
+		for element := range me.All() {
+			chunk = append(chunk, element)
+			if len(chunk) == size {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]E, 0, size)
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
 // Contains returns true if the element is in the SortedSet; otherwise
 // false. For example:
 //
@@ -176,138 +741,790 @@ func (me *SortedSet[E]) Contains(element E) bool {
 	return false
 }
 
-// Delete deletes the given element from the SortedSet and returns true, or
-// does nothing and returns false if the element is not in the SortedSet.
-// For example:
+// ContainsSorted returns a slice of bools the same length as sorted, with
+// each one true if the corresponding element of sorted is in the
+// SortedSet; otherwise false. The sorted slice must be in ascending order
+// (duplicates are fine); if it isn't, the results are unspecified. Rather
+// than doing an independent lookup for each element, ContainsSorted walks
+// the SortedSet and the slice together in O(n + m) time—unless the slice
+// is so short that independent lookups are cheaper. For example:
 //
-//	deleted := sset.Delete(element).
+//	found := sset.ContainsSorted(probes)
 //
-// See also [Clear]
-func (me *SortedSet[E]) Delete(element E) bool {
-	deleted := false
-	if me.root != nil {
-		if me.root, deleted = delete_(me.root, element); me.root != nil {
-			me.root.red = false
+// See also [SortedSet.Contains].
+func (me *SortedSet[E]) ContainsSorted(sorted []E) []bool {
+	found := make([]bool, len(sorted))
+	if fewEnough(len(sorted), me.size) {
+		for i, element := range sorted {
+			found[i] = me.Contains(element)
 		}
+		return found
 	}
-	if deleted {
-		me.size--
+	walker := newWalker(me.root)
+	current, ok := walker.next()
+	for i, element := range sorted {
+		for ok && current < element {
+			current, ok = walker.next()
+		}
+		if !ok {
+			break
+		}
+		found[i] = current == element
 	}
-	return deleted
+	return found
 }
 
-func delete_[E Comparable](root *node[E], element E) (*node[E], bool) {
-	deleted := false
-	if element < root.element {
-		if root.left != nil {
-			if !isRed(root.left) && !isRed(root.left.left) {
-				root = moveRedLeft(root)
-			}
-			root.left, deleted = delete_(root.left, element)
-		}
-	} else {
-		if isRed(root.left) {
-			root = rotateRight(root)
-		}
-		if element == root.element && root.right == nil {
-			return nil, true
-		}
-		if root.right != nil {
-			root, deleted = deleteRight(root, element)
+// Get returns the stored element that is equal to x and true; or the zero
+// value and false if there is no such element. For example:
+//
+//	canonical, ok := sset.Get(word)
+//
+// Since equal elements are identical for the types a SortedSet can hold,
+// Get is mostly useful for retrieving a particular stored instance (e.g.,
+// an interned string). See also [SortedSetFunc.Get].
+func (me *SortedSet[E]) Get(x E) (E, bool) {
+	root := me.root
+	for root != nil {
+		if x < root.element {
+			root = root.left
+		} else if root.element < x {
+			root = root.right
+		} else {
+			return root.element, true
 		}
 	}
-	return fixUp(root), deleted
+	var zero E
+	return zero, false
 }
 
-func moveRedLeft[E Comparable](root *node[E]) *node[E] {
-	colorFlip(root)
-	if root.right != nil && isRed(root.right.left) {
-		root.right = rotateRight(root.right)
-		root = rotateLeft(root)
-		colorFlip(root)
+// Min returns the SortedSet's smallest element and true; or the zero value
+// and false if the SortedSet is empty. For example:
+//
+//	element, ok := sset.Min().
+//
+// See also [SortedSet.Max].
+func (me *SortedSet[E]) Min() (E, bool) {
+	if me.root == nil {
+		var zero E
+		return zero, false
 	}
-	return root
+	return first(me.root).element, true
 }
 
-func deleteRight[E Comparable](root *node[E], element E) (*node[E], bool) {
-	deleted := false
-	if !isRed(root.right) && !isRed(root.right.left) {
-		root = moveRedRight(root)
-	}
-	if element == root.element {
-		smallest := first(root.right)
-		root.element = smallest.element
-		root.right = deleteMinimum(root.right)
-		deleted = true
-	} else {
-		root.right, deleted = delete_(root.right, element)
+// Max returns the SortedSet's largest element and true; or the zero value
+// and false if the SortedSet is empty. For example:
+//
+//	element, ok := sset.Max().
+//
+// See also [SortedSet.Min].
+func (me *SortedSet[E]) Max() (E, bool) {
+	if me.root == nil {
+		var zero E
+		return zero, false
 	}
-	return root, deleted
+	return last(me.root).element, true
 }
 
-func moveRedRight[E Comparable](root *node[E]) *node[E] {
-	colorFlip(root)
-	if root.left != nil && isRed(root.left.left) {
-		root = rotateRight(root)
-		colorFlip(root)
+// First returns the SortedSet's first (i.e., smallest) element and true;
+// or the zero value and false if the SortedSet is empty. It is the same as
+// [SortedSet.Min] and is provided for those who think of a SortedSet as a
+// sequence. See also [SortedSet.Last].
+func (me *SortedSet[E]) First() (E, bool) { return me.Min() }
+
+// Last returns the SortedSet's last (i.e., largest) element and true; or
+// the zero value and false if the SortedSet is empty. It is the same as
+// [SortedSet.Max]. See also [SortedSet.First].
+func (me *SortedSet[E]) Last() (E, bool) { return me.Max() }
+
+// Floor returns the largest element that is less than or equal to x and
+// true; or the zero value and false if there is no such element.
+// See also [SortedSet.Ceiling] and [SortedSet.Lower].
+func (me *SortedSet[E]) Floor(x E) (E, bool) {
+	var floor E
+	found := false
+	root := me.root
+	for root != nil {
+		if x < root.element {
+			root = root.left
+		} else if root.element < x {
+			floor, found = root.element, true
+			root = root.right
+		} else {
+			return root.element, true
+		}
 	}
-	return root
+	return floor, found
 }
 
-// We do not provide an exported First() method because this
-// is an implementation detail.
-func first[E Comparable](root *node[E]) *node[E] {
-	for root.left != nil {
-		root = root.left
+// Ceiling returns the smallest element that is greater than or equal to x
+// and true; or the zero value and false if there is no such element.
+// See also [SortedSet.Floor] and [SortedSet.Higher].
+func (me *SortedSet[E]) Ceiling(x E) (E, bool) {
+	var ceiling E
+	found := false
+	root := me.root
+	for root != nil {
+		if x < root.element {
+			ceiling, found = root.element, true
+			root = root.left
+		} else if root.element < x {
+			root = root.right
+		} else {
+			return root.element, true
+		}
 	}
-	return root
+	return ceiling, found
 }
 
-func deleteMinimum[E Comparable](root *node[E]) *node[E] {
-	if root.left == nil {
-		return nil
-	}
-	if !isRed(root.left) && !isRed(root.left.left) {
-		root = moveRedLeft(root)
+// Lower returns the largest element that is strictly less than x and
+// true; or the zero value and false if there is no such element. Unlike
+// [SortedSet.Floor], if x is in the SortedSet, Lower returns the element
+// before it. See also [SortedSet.Higher].
+func (me *SortedSet[E]) Lower(x E) (E, bool) {
+	var lower E
+	found := false
+	root := me.root
+	for root != nil {
+		if root.element < x {
+			lower, found = root.element, true
+			root = root.right
+		} else {
+			root = root.left
+		}
 	}
-	root.left = deleteMinimum(root.left)
-	return fixUp(root)
+	return lower, found
 }
 
-func fixUp[E Comparable](root *node[E]) *node[E] {
-	if isRed(root.right) {
-		root = rotateLeft(root)
+// Higher returns the smallest element that is strictly greater than x and
+// true; or the zero value and false if there is no such element. Unlike
+// [SortedSet.Ceiling], if x is in the SortedSet, Higher returns the
+// element after it. See also [SortedSet.Lower].
+func (me *SortedSet[E]) Higher(x E) (E, bool) {
+	var higher E
+	found := false
+	root := me.root
+	for root != nil {
+		if x < root.element {
+			higher, found = root.element, true
+			root = root.left
+		} else {
+			root = root.right
+		}
 	}
-	if isRed(root.left) && isRed(root.left.left) {
-		root = rotateRight(root)
+	return higher, found
+}
+
+// At returns the element at sorted index i (counting from 0) and true; or
+// the zero value and false if i is out of range. For example:
+//
+//	smallest, ok := sset.At(0)
+//	largest, ok := sset.At(sset.Len() - 1)
+func (me *SortedSet[E]) At(i int) (E, bool) {
+	if i < 0 || i >= me.size {
+		var zero E
+		return zero, false
 	}
-	if isRed(root.left) && isRed(root.right) {
-		colorFlip(root)
+	root := me.root
+	for {
+		leftSize := sizeOf(root.left)
+		if i < leftSize {
+			root = root.left
+		} else if i > leftSize {
+			i -= leftSize + 1
+			root = root.right
+		} else {
+			return root.element, true
+		}
 	}
-	return root
 }
 
-// Clear deletes all the elements in the SortedSet.
-// See also [Delete].
+// NthSmallest returns the n-th smallest element and true; or the zero
+// value and false if n is out of range. Note that n counts from 1 (unlike
+// [SortedSet.At]'s index which counts from 0). For example:
+//
+//	smallest, ok := sset.NthSmallest(1) // same as sset.At(0)
+//	third, ok := sset.NthSmallest(3)    // same as sset.At(2)
+//
+// See also [SortedSet.NthLargest].
+func (me *SortedSet[E]) NthSmallest(n int) (E, bool) {
+	if n < 1 {
+		var zero E
+		return zero, false
+	}
+	return me.At(n - 1)
+}
+
+// NthLargest returns the n-th largest element and true; or the zero value
+// and false if n is out of range. Note that n counts from 1 (unlike
+// [SortedSet.At]'s index which counts from 0). For example:
+//
+//	largest, ok := sset.NthLargest(1) // same as sset.At(sset.Len() - 1)
+//	third, ok := sset.NthLargest(3)   // same as sset.At(sset.Len() - 3)
+//
+// See also [SortedSet.NthSmallest].
+func (me *SortedSet[E]) NthLargest(n int) (E, bool) {
+	if n < 1 {
+		var zero E
+		return zero, false
+	}
+	return me.At(me.size - n)
+}
+
+// PopAt deletes the element at sorted index i (counting from 0) and returns
+// it and true; or does nothing and returns the zero value and false if i is
+// out of range. The element is found and deleted in a single descent of
+// the tree. For example:
+//
+//	element, ok := sset.PopAt(rng.Intn(sset.Len()))
+//
+// See also [SortedSet.At] and [SortedSet.Delete].
+func (me *SortedSet[E]) PopAt(i int) (E, bool) {
+	var zero E
+	return me.remove(zero, i, deleteAt)
+}
+
+// Sample returns k distinct elements chosen uniformly at random, in
+// ascending order. The random numbers come from rng, or from the
+// math/rand package's default source if rng is nil. If k >= Len() all the
+// elements are returned; if k <= 0 the returned slice is empty. Only k
+// random indexes are generated, each resolved in O(log n) time, so this is
+// efficient even when k is much smaller than Len(). For example:
+//
+//	sample := sset.Sample(10, rand.New(rand.NewSource(seed)))
+func (me *SortedSet[E]) Sample(k int, rng *rand.Rand) []E {
+	if k >= me.Len() {
+		return me.ToSlice()
+	}
+	if k <= 0 {
+		return []E{}
+	}
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+	// Robert Floyd's algorithm for choosing k distinct indexes
+	chosen := make(map[int]bool, k)
+	for j := me.Len() - k; j < me.Len(); j++ {
+		if i := intn(j + 1); chosen[i] {
+			chosen[j] = true
+		} else {
+			chosen[i] = true
+		}
+	}
+	indexes := slices.Sorted(maps.Keys(chosen))
+	sample := make([]E, 0, k)
+	for _, i := range indexes {
+		element, _ := me.At(i)
+		sample = append(sample, element)
+	}
+	return sample
+}
+
+// Rank returns the number of elements that are less than x, i.e., the
+// sorted index that x has (or would have if it was added).
+// See also [SortedSet.At].
+func (me *SortedSet[E]) Rank(x E) int {
+	rank := 0
+	root := me.root
+	for root != nil {
+		if x < root.element {
+			root = root.left
+		} else if root.element < x {
+			rank += sizeOf(root.left) + 1
+			root = root.right
+		} else {
+			return rank + sizeOf(root.left)
+		}
+	}
+	return rank
+}
+
+// CountBetween returns the number of elements that are greater than or
+// equal to lo and less than hi; or 0 if lo > hi.
+func (me *SortedSet[E]) CountBetween(lo, hi E) int {
+	if hi < lo {
+		return 0
+	}
+	return me.Rank(hi) - me.Rank(lo)
+}
+
+// RemoveRange deletes every element that is greater than or equal to lo
+// and less than hi and returns how many were deleted; or does nothing and
+// returns 0 if lo >= hi. If only a few elements are in the range they are
+// deleted one at a time; otherwise the tree is rebuilt from the elements
+// either side of the range. For example:
+//
+//	count := sset.RemoveRange(lo, hi)
+//
+// See also [SortedSet.Between] and [SortedSet.CountBetween].
+func (me *SortedSet[E]) RemoveRange(lo, hi E) int {
+	if !(lo < hi) {
+		return 0
+	}
+	count := me.CountBetween(lo, hi)
+	if count == 0 {
+		return 0
+	}
+	if fewEnough(count, me.size) {
+		unwanted := make([]E, 0, count)
+		between(me.root, &lo, &hi, func(element E) bool {
+			unwanted = append(unwanted, element)
+			return true
+		})
+		for _, element := range unwanted {
+			me.Delete(element)
+		}
+	} else {
+		wanted := make([]E, 0, me.size-count)
+		appender := func(element E) bool {
+			wanted = append(wanted, element)
+			return true
+		}
+		between(me.root, nil, &lo, appender)
+		between(me.root, &hi, nil, appender)
+		me.replace(fromSorted(wanted))
+	}
+	return count
+}
+
+// KeepSmallest deletes all but the k smallest elements and returns how
+// many were deleted; or does nothing and returns 0 if k >= Len(). If k <= 0
+// the SortedSet is cleared. For example:
+//
+//	sset.KeepSmallest(limit)
+//
+// See also [SortedSet.KeepLargest] and [SortedSet.RemoveRange].
+func (me *SortedSet[E]) KeepSmallest(k int) int {
+	count := me.size - max(k, 0)
+	if count <= 0 {
+		return 0
+	}
+	if k <= 0 {
+		me.Clear()
+	} else if fewEnough(count, me.size) {
+		for range count {
+			me.DeleteMax()
+		}
+	} else {
+		me.replace(fromSorted(me.ToSliceRange(0, k)))
+	}
+	return count
+}
+
+// KeepLargest deletes all but the k largest elements and returns how many
+// were deleted; or does nothing and returns 0 if k >= Len(). If k <= 0 the
+// SortedSet is cleared. For example:
+//
+//	sset.KeepLargest(limit)
+//
+// See also [SortedSet.KeepSmallest] and [NewBounded].
+func (me *SortedSet[E]) KeepLargest(k int) int {
+	count := me.size - max(k, 0)
+	if count <= 0 {
+		return 0
+	}
+	if k <= 0 {
+		me.Clear()
+	} else if fewEnough(count, me.size) {
+		for range count {
+			me.DeleteMin()
+		}
+	} else {
+		me.replace(fromSorted(me.ToSliceRange(count, me.size)))
+	}
+	return count
+}
+
+// CutRange deletes every element that is greater than or equal to lo and
+// less than hi from this SortedSet and returns them in a new SortedSet;
+// or does nothing and returns an empty SortedSet if lo >= hi. For example:
+//
+//	moved := shard1.CutRange(lo, hi)
+//	shard2.Unite(moved)
+//
+// See also [SortedSet.RemoveRange] and [SortedSet.SubSet].
+func (me *SortedSet[E]) CutRange(lo, hi E) SortedSet[E] {
+	if !(lo < hi) {
+		return SortedSet[E]{}
+	}
+	cut := me.rangeSet(&lo, &hi)
+	me.RemoveRange(lo, hi)
+	return cut
+}
+
+// CoversRange returns true if every integer from lo to hi inclusive is in
+// the given SortedSet; otherwise returns false. If hi < lo the range is
+// empty so the result is true. This takes O(log n) time however wide the
+// range is, since it only needs to count the elements in the range. For
+// example:
+//
+//	ok := CoversRange(ids, 100, 200)
+func CoversRange[E Integer](sset SortedSet[E], lo, hi E) bool {
+	if hi < lo {
+		return true
+	}
+	width := uint64(hi) - uint64(lo) // exact even if hi - lo overflows E
+	if width >= uint64(sset.Len()) {
+		return false // too few elements to cover the range
+	}
+	count := sset.CountBetween(lo, hi)
+	if sset.Contains(hi) {
+		count++
+	}
+	return uint64(count) == width+1
+}
+
+// Delete deletes the given element from the SortedSet and returns true, or
+// does nothing and returns false if the element is not in the SortedSet.
+// For example:
+//
+//	deleted := sset.Delete(element).
+//
+// See also [Clear]
+func (me *SortedSet[E]) Delete(element E) bool {
+	_, deleted := me.remove(element, 0, deleteElement)
+	return deleted
+}
+
+// Take deletes x from the SortedSet and returns it and true; or does
+// nothing and returns the zero value and false if x is not in the
+// SortedSet. For example:
+//
+//	element, ok := sset.Take(x).
+//
+// See also [SortedSetFunc.Take].
+func (me *SortedSet[E]) Take(x E) (E, bool) {
+	if me.Delete(x) {
+		return x, true
+	}
+	var zero E
+	return zero, false
+}
+
+// DeleteAll deletes each of the given elements that is present from the
+// SortedSet and returns how many were deleted. For example:
+//
+//	count := sset.DeleteAll(element1, element2, element3).
+//
+// See also [SortedSet.Delete] and [SortedSet.AddAll].
+func (me *SortedSet[E]) DeleteAll(elements ...E) int {
+	count := 0
+	for _, element := range elements {
+		if me.Delete(element) {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteFunc deletes every element for which pred returns true and
+// returns how many were deleted. The elements are passed to pred in
+// ascending order and are only deleted once they have all been checked, so
+// pred sees the unchanged SortedSet. For example:
+//
+//	count := sset.DeleteFunc(func(x int) bool { return x < cutoff })
+//
+// See also [SortedSet.Filter] and [SortedSet.RemoveRange].
+func (me *SortedSet[E]) DeleteFunc(pred func(E) bool) int {
+	var wanted, unwanted []E
+	for element := range me.All() {
+		if pred(element) {
+			unwanted = append(unwanted, element)
+		} else {
+			wanted = append(wanted, element)
+		}
+	}
+	if len(unwanted) == 0 {
+		return 0
+	}
+	if fewEnough(len(unwanted), me.size) {
+		for _, element := range unwanted {
+			me.Delete(element)
+		}
+	} else {
+		me.replace(fromSorted(wanted))
+	}
+	return len(unwanted)
+}
+
+// DeleteMin deletes the SortedSet's smallest element and returns true; or
+// does nothing and returns false if the SortedSet is empty.
+// See also [SortedSet.DeleteMax].
+func (me *SortedSet[E]) DeleteMin() bool {
+	var zero E
+	_, deleted := me.remove(zero, 0, deleteMin)
+	return deleted
+}
+
+// DeleteMax deletes the SortedSet's largest element and returns true; or
+// does nothing and returns false if the SortedSet is empty.
+// See also [SortedSet.DeleteMin].
+func (me *SortedSet[E]) DeleteMax() bool {
+	var zero E
+	_, deleted := me.remove(zero, 0, deleteMax)
+	return deleted
+}
+
+type deleteTarget uint8
+
+const (
+	deleteElement deleteTarget = iota
+	deleteMin
+	deleteMax
+	deleteAt
+)
+
+// remove deletes the given element, or the element at the given index,
+// or the smallest or largest element, depending on the target, and returns
+// the deleted element and true; or does nothing and returns the zero value
+// and false if there's nothing to delete.
+//
+// This is an iterative version of the classic recursive left-leaning
+// red-black tree deletion algorithm, performing the same transformations
+// on the way down, and then the same fixUps on the way back up using an
+// explicit path stack. None of the transformations change the in-order
+// sequence of a subtree, so the index stays correct relative to root.
+func (me *SortedSet[E]) remove(element E, index int,
+	target deleteTarget,
+) (E, bool) {
+	var removed E
+	if me.root == nil || (target == deleteElement && !me.Contains(element)) ||
+		(target == deleteAt && (index < 0 || index >= me.size)) {
+		return removed, false // Nothing to delete so don't copy shared nodes
+	}
+	var path [maxHeight]*node[E]
+	var wentLeft [maxHeight]bool
+	depth := 0
+	deleted := false
+	root := me.root
+	for {
+		root = mutable(me.cow, root)
+		if target == deleteMin || (target == deleteElement &&
+			element < root.element) || (target == deleteAt &&
+			index < sizeOf(root.left)) {
+			if root.left == nil {
+				if target == deleteMin { // root is the minimum
+					if !deleted { // else root is a successor that moved up
+						removed = root.element
+					}
+					deleted, root = true, nil
+				} else { // element isn't present
+					root = fixUp(me.cow, root)
+				}
+				break
+			}
+			if !isRed(root.left) && !isRed(root.left.left) {
+				root = moveRedLeft(me.cow, root)
+			}
+			path[depth], wentLeft[depth] = root, true
+			root = root.left
+		} else {
+			if isRed(root.left) {
+				root = rotateRight(me.cow, root)
+			}
+			if root.right == nil {
+				if target == deleteMax || (target == deleteElement &&
+					element == root.element) || (target == deleteAt &&
+					index == sizeOf(root.left)) {
+					removed, deleted, root = root.element, true, nil
+				} else { // element isn't present
+					root = fixUp(me.cow, root)
+				}
+				break
+			}
+			if !isRed(root.right) && !isRed(root.right.left) {
+				root = moveRedRight(me.cow, root)
+			}
+			if (target == deleteElement && element == root.element) ||
+				(target == deleteAt && index == sizeOf(root.left)) {
+				// Replace the element with its successor and delete that
+				removed = root.element
+				root.element = first(root.right).element
+				target = deleteMin
+				deleted = true
+			}
+			index -= sizeOf(root.left) + 1
+			path[depth], wentLeft[depth] = root, false
+			root = root.right
+		}
+		depth++
+	}
+	for depth > 0 { // Fix up on the way back up
+		depth--
+		parent := path[depth]
+		if wentLeft[depth] {
+			parent.left = root
+		} else {
+			parent.right = root
+		}
+		root = fixUp(me.cow, parent)
+	}
+	if me.root = root; me.root != nil {
+		me.root.red = false
+	}
+	if deleted {
+		me.size--
+		me.mods++
+	}
+	return removed, deleted
+}
+
+func moveRedLeft[E any](cow *cowToken, root *node[E]) *node[E] {
+	colorFlip(cow, root)
+	if root.right != nil && isRed(root.right.left) {
+		root.right = rotateRight(cow, root.right)
+		root = rotateLeft(cow, root)
+		colorFlip(cow, root)
+	}
+	return root
+}
+
+func moveRedRight[E any](cow *cowToken, root *node[E]) *node[E] {
+	colorFlip(cow, root)
+	if root.left != nil && isRed(root.left.left) {
+		root = rotateRight(cow, root)
+		colorFlip(cow, root)
+	}
+	return root
+}
+
+// first returns the leftmost (i.e., smallest) node of a non-empty tree;
+// see [SortedSet.First] (and [SortedSet.Min]) for the exported API.
+func first[E any](root *node[E]) *node[E] {
+	for root.left != nil {
+		root = root.left
+	}
+	return root
+}
+
+func last[E any](root *node[E]) *node[E] {
+	for root.right != nil {
+		root = root.right
+	}
+	return root
+}
+
+func deleteMinimum[E any](cow *cowToken, root *node[E]) *node[E] {
+	if root.left == nil {
+		return nil
+	}
+	root = mutable(cow, root)
+	if !isRed(root.left) && !isRed(root.left.left) {
+		root = moveRedLeft(cow, root)
+	}
+	root.left = deleteMinimum(cow, root.left)
+	return fixUp(cow, root)
+}
+
+func fixUp[E any](cow *cowToken, root *node[E]) *node[E] {
+	resize(root)
+	if isRed(root.right) {
+		root = rotateLeft(cow, root)
+	}
+	if isRed(root.left) && isRed(root.left.left) {
+		root = rotateRight(cow, root)
+	}
+	if isRed(root.left) && isRed(root.right) {
+		colorFlip(cow, root)
+	}
+	return root
+}
+
+// Clear deletes all the elements in the SortedSet.
+// See also [Delete].
 func (me *SortedSet[E]) Clear() {
 	me.root = nil
 	me.size = 0
+	me.mods++
+}
+
+// ClearRetaining deletes all the elements in the SortedSet like
+// [SortedSet.Clear], but keeps the tree's nodes for reuse by subsequent
+// additions. This reduces allocations when the same SortedSet is
+// repeatedly filled and cleared. Nodes shared with a snapshot (see
+// [SortedSet.Snapshot]) are not kept. Note that kept nodes aren't freed
+// until the SortedSet itself is freed.
+func (me *SortedSet[E]) ClearRetaining() {
+	alloc := me.allocator()
+	if alloc == nil {
+		alloc = &allocator[E]{claimed: true}
+		me.alloc, me.owner = alloc, true
+	}
+	recycle(alloc, me.cow, me.root)
+	me.Clear()
+}
+
+// recycle adds the given subtree's nodes to the allocator's free list,
+// skipping any that are shared.
+func recycle[E Comparable](alloc *allocator[E], cow *cowToken,
+	root *node[E],
+) {
+	if root == nil || root.cow != cow {
+		return
+	}
+	recycle(alloc, cow, root.left)
+	recycle(alloc, cow, root.right)
+	*root = node[E]{left: alloc.free} // drop the element for the GC
+	alloc.free = root
+}
+
+// replace replaces the SortedSet's contents with those of other, which
+// must not share any nodes with this or any other SortedSet. This
+// SortedSet keeps its spare nodes (if any), and if it owns them, its old
+// tree's unshared nodes are added to them.
+func (me *SortedSet[E]) replace(other SortedSet[E]) {
+	alloc, owner, mods := me.alloc, me.owner, me.mods
+	if owner {
+		recycle(alloc, me.cow, me.root)
+	}
+	*me = other
+	me.alloc, me.owner, me.mods = alloc, owner, mods+1
 }
 
 // IsEmpty returns true if there are no elements in the set; otherwise
 // returns false.
 func (me *SortedSet[E]) IsEmpty() bool { return me.size == 0 }
 
+// IsSingleton returns true if there is exactly one element in the set;
+// otherwise returns false.
+func (me *SortedSet[E]) IsSingleton() bool { return me.size == 1 }
+
 // Difference returns a new SortedSet that contains the elements which are
 // in this SortedSet that are not in the other SortedSet.
 func (me *SortedSet[E]) Difference(other SortedSet[E]) SortedSet[E] {
-	diff := New[E]()
-	for element := range me.All() {
-		if !other.Contains(element) {
-			diff.Add(element)
+	return fromSorted(mergeDifference(me.ToSlice(), other.ToSlice()))
+}
+
+// ComplementIn returns a new SortedSet that contains the elements of the
+// universe SortedSet that are not in this SortedSet, computed with a
+// single merge. Any elements of this SortedSet that are not in universe
+// are simply ignored. For example:
+//
+//	disabled := enabled.ComplementIn(known)
+//
+// This is the same as universe.Difference(sset).
+// See also [SortedSet.Difference].
+func (me *SortedSet[E]) ComplementIn(universe SortedSet[E]) SortedSet[E] {
+	return fromSorted(mergeDifference(universe.ToSlice(), me.ToSlice()))
+}
+
+// mergeDifference returns the sorted elements of sorted slice a that
+// aren't in sorted slice b.
+func mergeDifference[E Comparable](a, b []E) []E {
+	diff := make([]E, 0, len(a))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] < b[j] {
+			diff = append(diff, a[i])
+			i++
+		} else if b[j] < a[i] {
+			j++
+		} else {
+			i++
+			j++
 		}
 	}
-	return diff
+	return append(diff, a[i:]...)
 }
 
 // SymmetricDifference returns a new SortedSet that contains the elements
@@ -331,10 +1548,23 @@ func (me *SortedSet[E]) SymmetricDifference(other SortedSet[E]) SortedSet[E] {
 // Intersection returns a new SortedSet that contains the elements this
 // SortedSet has in common with the other SortedSet.
 func (me *SortedSet[E]) Intersection(other SortedSet[E]) SortedSet[E] {
-	intersection := New[E]()
-	for element := range me.All() {
-		if other.Contains(element) {
-			intersection.Add(element)
+	return fromSorted(mergeIntersection(me.ToSlice(), other.ToSlice()))
+}
+
+// mergeIntersection returns the sorted intersection of the two given
+// sorted slices.
+func mergeIntersection[E Comparable](a, b []E) []E {
+	intersection := make([]E, 0, min(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] < b[j] {
+			i++
+		} else if b[j] < a[i] {
+			j++
+		} else {
+			intersection = append(intersection, a[i])
+			i++
+			j++
 		}
 	}
 	return intersection
@@ -344,9 +1574,28 @@ func (me *SortedSet[E]) Intersection(other SortedSet[E]) SortedSet[E] {
 // SortedSet and from the other SortedSet (with no duplicates of course).
 // See also [SortedSet.Unite].
 func (me *SortedSet[E]) Union(other SortedSet[E]) SortedSet[E] {
-	union := me.Clone()
-	union.Unite(other)
-	return union
+	return fromSorted(mergeUnion(me.ToSlice(), other.ToSlice()))
+}
+
+// mergeUnion returns the sorted union of the two given sorted slices.
+func mergeUnion[E Comparable](a, b []E) []E {
+	union := make([]E, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] < b[j] {
+			union = append(union, a[i])
+			i++
+		} else if b[j] < a[i] {
+			union = append(union, b[j])
+			j++
+		} else {
+			union = append(union, a[i])
+			i++
+			j++
+		}
+	}
+	union = append(union, a[i:]...)
+	return append(union, b[j:]...)
 }
 
 // Unite adds all the elements from other that aren't already in this
@@ -358,6 +1607,240 @@ func (me *SortedSet[E]) Unite(other SortedSet[E]) {
 	}
 }
 
+// Absorb moves all the elements from other into this SortedSet, leaving
+// other empty. If all of other's elements are less than all of this
+// SortedSet's elements, or vice versa, the two trees are joined in
+// O(log n) time, reusing all their nodes. Otherwise other's elements are
+// added one at a time as by [SortedSet.Unite]. For example:
+//
+//	for _, partial := range partials {
+//		results.Absorb(&partial)
+//	}
+func (me *SortedSet[E]) Absorb(other *SortedSet[E]) {
+	if other == me || other.IsEmpty() {
+		return
+	}
+	// When other's nodes are taken over they may still be shared with
+	// other's snapshots, which might have the same token as this
+	// SortedSet, so a fresh token ensures that they are copied on write.
+	var zero E
+	if me.IsEmpty() {
+		me.root, me.size = other.root, other.size
+		me.cow = new(cowToken)
+		me.mods++
+	} else if last(me.root).element < first(other.root).element {
+		pivot, _ := other.remove(zero, 0, deleteMin)
+		me.cow = new(cowToken)
+		me.root = join(me.cow, me.root, me.newNode(pivot), other.root)
+		me.size += other.size + 1
+		me.mods++
+	} else if last(other.root).element < first(me.root).element {
+		pivot, _ := me.remove(zero, 0, deleteMin)
+		me.cow = new(cowToken)
+		me.root = join(me.cow, other.root, me.newNode(pivot), me.root)
+		me.size += other.size + 1
+		me.mods++
+	} else {
+		me.Unite(*other)
+	}
+	other.Clear()
+}
+
+// join returns the root of a tree containing the left tree's elements,
+// the pivot node's element, and the right tree's elements, given that the
+// left tree's elements are all less than the pivot's element which is
+// less than all the right tree's elements. The pivot is placed on the
+// spine of the taller tree at the height of the shorter one and then the
+// tree is rebalanced on the way back up, just as for an insertion.
+func join[E any](cow *cowToken, left, pivot, right *node[E]) *node[E] {
+	var path [maxHeight]*node[E]
+	depth := 0
+	leftHeight, rightHeight := blackHeight(left), blackHeight(right)
+	root := pivot
+	if leftHeight >= rightHeight { // Descend left's right spine
+		root = left
+		for height := leftHeight; height > rightHeight; height-- {
+			path[depth] = mutable(cow, root)
+			root = root.right
+			depth++
+		}
+		pivot.left, pivot.right = root, right
+	} else { // Descend right's left spine
+		root = right
+		for height := rightHeight; height > leftHeight || isRed(root); {
+			if !isRed(root) {
+				height--
+			}
+			path[depth] = mutable(cow, root)
+			root = root.left
+			depth++
+		}
+		pivot.left, pivot.right = left, root
+	}
+	pivot.red = true
+	resize(pivot)
+	root = pivot
+	for depth > 0 { // Rebalance on the way back up
+		depth--
+		parent := path[depth]
+		if leftHeight >= rightHeight {
+			parent.right = root
+		} else {
+			parent.left = root
+		}
+		resize(parent)
+		root = insertRotation(cow, parent)
+	}
+	root.red = false
+	return root
+}
+
+// Subtract deletes all the elements from this SortedSet that are in the
+// other SortedSet.
+// See also [SortedSet.Difference].
+func (me *SortedSet[E]) Subtract(other SortedSet[E]) {
+	if me.root == other.root { // other is (a copy of) this SortedSet
+		me.Clear()
+		return
+	}
+	for element := range other.All() {
+		me.Delete(element)
+	}
+}
+
+// RetainAll deletes all the elements from this SortedSet that are not in
+// the other SortedSet.
+// See also [SortedSet.Intersection].
+func (me *SortedSet[E]) RetainAll(other SortedSet[E]) {
+	var unwanted []E
+	for element := range me.All() {
+		if !other.Contains(element) {
+			unwanted = append(unwanted, element)
+		}
+	}
+	for _, element := range unwanted {
+		me.Delete(element)
+	}
+}
+
+// SymmetricDifferenceUpdate changes this SortedSet so that it contains
+// only those elements which were in this SortedSet or the other
+// SortedSet—but not in both SortedSets.
+// See also [SortedSet.SymmetricDifference].
+func (me *SortedSet[E]) SymmetricDifferenceUpdate(other SortedSet[E]) {
+	if me.root == other.root { // other is (a copy of) this SortedSet
+		me.Clear()
+		return
+	}
+	for element := range other.All() {
+		if !me.Delete(element) {
+			me.Add(element)
+		}
+	}
+}
+
+// UnionAll returns a new SortedSet that contains the elements from all the
+// given SortedSets (with no duplicates of course). This is more efficient
+// than chaining calls to [SortedSet.Union].
+func UnionAll[E Comparable](ssets ...SortedSet[E]) SortedSet[E] {
+	if len(ssets) == 0 {
+		return SortedSet[E]{}
+	}
+	sorted := make([][]E, 0, len(ssets))
+	for _, sset := range ssets {
+		sorted = append(sorted, sset.ToSlice())
+	}
+	for len(sorted) > 1 { // Merge pairwise until only one slice is left
+		merged := make([][]E, 0, (len(sorted)+1)/2)
+		for i := 0; i < len(sorted); i += 2 {
+			if i+1 < len(sorted) {
+				merged = append(merged, mergeUnion(sorted[i], sorted[i+1]))
+			} else {
+				merged = append(merged, sorted[i])
+			}
+		}
+		sorted = merged
+	}
+	return fromSorted(sorted[0])
+}
+
+// IntersectionAll returns a new SortedSet that contains the elements that
+// all the given SortedSets have in common; or an empty SortedSet if no
+// SortedSets are given. This is more efficient than chaining calls to
+// [SortedSet.Intersection].
+func IntersectionAll[E Comparable](ssets ...SortedSet[E]) SortedSet[E] {
+	if len(ssets) == 0 {
+		return SortedSet[E]{}
+	}
+	smallest := 0
+	for i, sset := range ssets {
+		if sset.Len() < ssets[smallest].Len() {
+			smallest = i
+		}
+	}
+	intersection := ssets[smallest].ToSlice()
+	for i, sset := range ssets {
+		if len(intersection) == 0 {
+			break
+		}
+		if i != smallest {
+			intersection = mergeIntersection(intersection, sset.ToSlice())
+		}
+	}
+	return fromSorted(intersection)
+}
+
+// CommonAll returns a for .. range iterable of the elements that all the
+// given SortedSets have in common, in ascending order, e.g.,
+// for element := range CommonAll(a, b, c)
+// Unlike [IntersectionAll] nothing is built: the SortedSets are walked
+// together and each common element is yielded as soon as it is found, so
+// breaking out of the loop early avoids most of the work. If no SortedSets
+// are given nothing is yielded; if just one is given all its elements are
+// yielded.
+func CommonAll[E Comparable](ssets ...SortedSet[E]) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		if len(ssets) == 0 {
+			return
+		}
+		walkers := make([]*walker[E], len(ssets))
+		heads := make([]E, len(ssets))
+		for i, sset := range ssets {
+			walkers[i] = newWalker(sset.root)
+			ok := false
+			if heads[i], ok = walkers[i].next(); !ok {
+				return
+			}
+		}
+		for {
+			candidate := slices.Max(heads)
+			common := true
+			for i := range heads {
+				for heads[i] < candidate {
+					ok := false
+					if heads[i], ok = walkers[i].next(); !ok {
+						return
+					}
+				}
+				if candidate < heads[i] {
+					common = false
+				}
+			}
+			if common {
+				if !yield(candidate) {
+					return
+				}
+				for i := range heads {
+					ok := false
+					if heads[i], ok = walkers[i].next(); !ok {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
 // IsDisjoint returns true if this SortedSet has no elements in common with
 // the other SortedSet; otherwise returns false.
 func (me *SortedSet[E]) IsDisjoint(other SortedSet[E]) bool {
@@ -372,10 +1855,35 @@ func (me *SortedSet[E]) IsDisjoint(other SortedSet[E]) bool {
 // IsSubsetOf returns true if this SortedSet is a subset of the other
 // SortedSet, i.e., if every member of this SortedSet is in the other
 // SortedSet; otherwise returns false.
+//
+// If this SortedSet is much smaller than the other, each of its elements
+// is looked up in the other, taking O(n log m) time; otherwise the two
+// SortedSets are walked in lockstep, taking O(n + m) time.
 func (me *SortedSet[E]) IsSubsetOf(other SortedSet[E]) bool {
+	if me.Len() > other.Len() {
+		return false
+	}
+	if me.root == other.root { // other is (a copy of) this SortedSet
+		return true
+	}
+	if fewEnough(me.Len(), other.Len()) {
+		for element := range me.All() {
+			if !other.Contains(element) {
+				return false
+			}
+		}
+		return true
+	}
+	walker := newWalker(other.root)
 	for element := range me.All() {
-		if !other.Contains(element) {
-			return false
+		for {
+			otherElement, ok := walker.next()
+			if !ok || element < otherElement {
+				return false // other has run out or skipped over element
+			}
+			if otherElement == element {
+				break
+			}
 		}
 	}
 	return true
@@ -388,20 +1896,429 @@ func (me SortedSet[E]) IsSupersetOf(other SortedSet[E]) bool {
 	return other.IsSubsetOf(me)
 }
 
+// IsProperSubsetOf returns true if this SortedSet is a proper subset of
+// the other SortedSet, i.e., if every member of this SortedSet is in the
+// other SortedSet and the other SortedSet has at least one element that
+// isn't in this SortedSet; otherwise returns false.
+func (me *SortedSet[E]) IsProperSubsetOf(other SortedSet[E]) bool {
+	return me.Len() < other.Len() && me.IsSubsetOf(other)
+}
+
+// IsProperSupersetOf returns true if this SortedSet is a proper superset
+// of the other SortedSet, i.e., if every member of the other SortedSet is
+// in this SortedSet and this SortedSet has at least one element that isn't
+// in the other SortedSet; otherwise returns false.
+func (me *SortedSet[E]) IsProperSupersetOf(other SortedSet[E]) bool {
+	return other.IsProperSubsetOf(*me)
+}
+
 // Equal returns true if this SortedSet has the same elements as the other
 // SortedSet; otherwise returns false.
 func (me *SortedSet[E]) Equal(other SortedSet[E]) bool {
 	if me.Len() != other.Len() {
 		return false
 	}
+	if me.root == other.root { // other is (a copy of) this SortedSet
+		return true
+	}
+	walker := newWalker(other.root)
 	for element := range me.All() {
-		if !other.Contains(element) {
+		if otherElement, _ := walker.next(); element != otherElement {
 			return false
 		}
 	}
 	return true
 }
 
+// EqualElements returns true if seq yields exactly this SortedSet's
+// elements in ascending order; otherwise returns false. The comparison
+// stops at the first difference, so seq is assumed to be in ascending
+// order (e.g., the All() iterator of some other kind of ordered set). For
+// example:
+//
+//	ok := sset.EqualElements(slices.Values(sortedElements))
+//
+// See also [SortedSet.Equal].
+func (me *SortedSet[E]) EqualElements(seq iter.Seq[E]) bool {
+	walker := newWalker(me.root)
+	for x := range seq {
+		if element, ok := walker.next(); !ok || element != x {
+			return false
+		}
+	}
+	_, more := walker.next()
+	return !more
+}
+
+// walker supports in-order traversal of a tree one element at a time
+// (e.g., for walking two trees in lockstep).
+type walker[E any] struct {
+	stack []*node[E]
+}
+
+func newWalker[E any](root *node[E]) *walker[E] {
+	walker := &walker[E]{stack: make([]*node[E], 0, maxHeight)}
+	walker.pushLeft(root)
+	return walker
+}
+
+// newWalkerAt returns a walker whose first element is the one at sorted
+// index i (counting from 0).
+func newWalkerAt[E any](root *node[E], i int) *walker[E] {
+	walker := &walker[E]{stack: make([]*node[E], 0, maxHeight)}
+	for root != nil {
+		leftSize := sizeOf(root.left)
+		if i < leftSize {
+			walker.stack = append(walker.stack, root)
+			root = root.left
+		} else if i > leftSize {
+			i -= leftSize + 1
+			root = root.right
+		} else {
+			walker.stack = append(walker.stack, root)
+			break
+		}
+	}
+	return walker
+}
+
+// next returns the next element and true; or the zero value and false if
+// there are no more elements.
+func (me *walker[E]) next() (E, bool) {
+	if len(me.stack) == 0 {
+		var zero E
+		return zero, false
+	}
+	root := me.stack[len(me.stack)-1]
+	me.stack = me.stack[:len(me.stack)-1]
+	me.pushLeft(root.right)
+	return root.element, true
+}
+
+func (me *walker[E]) pushLeft(root *node[E]) {
+	for ; root != nil; root = root.left {
+		me.stack = append(me.stack, root)
+	}
+}
+
+// Jaccard returns the Jaccard index of this SortedSet and the other
+// SortedSet, i.e., the size of their intersection divided by the size of
+// their union, a value in the range [0, 1]. By convention, if both
+// SortedSets are empty, the result is 1.
+func (me *SortedSet[E]) Jaccard(other SortedSet[E]) float64 {
+	if me.IsEmpty() && other.IsEmpty() {
+		return 1
+	}
+	intersectionSize := me.OverlapCount(other)
+	unionSize := me.Len() + other.Len() - intersectionSize
+	return float64(intersectionSize) / float64(unionSize)
+}
+
+// OverlapCount returns the number of elements this SortedSet has in common
+// with the other SortedSet, i.e., the size of their intersection, computed
+// in a single merge-style pass without creating the intersection.
+// See also [SortedSet.Intersection].
+func (me *SortedSet[E]) OverlapCount(other SortedSet[E]) int {
+	return me.overlap(other, min(me.Len(), other.Len()))
+}
+
+// DifferenceCount returns the number of elements in this SortedSet that
+// aren't in the other SortedSet, i.e., the size of their difference,
+// computed in a single merge-style pass without creating the difference.
+// See also [SortedSet.Difference] and [SortedSet.OverlapCount].
+func (me *SortedSet[E]) DifferenceCount(other SortedSet[E]) int {
+	if me.root == other.root { // other is (a copy of) this SortedSet
+		return 0
+	}
+	otherWalker := newWalker(other.root)
+	otherElement, otherOk := otherWalker.next()
+	count := 0
+	for element := range me.All() {
+		for otherOk && otherElement < element {
+			otherElement, otherOk = otherWalker.next()
+		}
+		if !otherOk || element != otherElement {
+			count++
+		}
+	}
+	return count
+}
+
+// SharesAtLeast returns true if this SortedSet and the other SortedSet
+// have at least n elements in common; otherwise returns false. The
+// merge-style pass stops as soon as n common elements have been found, so
+// this can be much faster than [SortedSet.OverlapCount]. If n <= 0 the
+// result is always true.
+func (me *SortedSet[E]) SharesAtLeast(other SortedSet[E], n int) bool {
+	if n <= 0 {
+		return true
+	}
+	if n > min(me.Len(), other.Len()) {
+		return false
+	}
+	return me.overlap(other, n) == n
+}
+
+// overlap returns the number of elements this SortedSet has in common with
+// the other SortedSet, stopping if the count reaches limit.
+func (me *SortedSet[E]) overlap(other SortedSet[E], limit int) int {
+	walker := newWalker(me.root)
+	otherWalker := newWalker(other.root)
+	count := 0
+	element, ok := walker.next()
+	otherElement, otherOk := otherWalker.next()
+	for ok && otherOk && count < limit {
+		if element < otherElement {
+			element, ok = walker.next()
+		} else if otherElement < element {
+			otherElement, otherOk = otherWalker.next()
+		} else {
+			count++
+			element, ok = walker.next()
+			otherElement, otherOk = otherWalker.next()
+		}
+	}
+	return count
+}
+
+// Filter returns a new SortedSet that contains the elements of this
+// SortedSet for which keep returns true. For example:
+//
+//	evens := sset.Filter(func(x int) bool { return x%2 == 0 })
+func (me *SortedSet[E]) Filter(keep func(E) bool) SortedSet[E] {
+	var elements []E
+	for element := range me.All() {
+		if keep(element) {
+			elements = append(elements, element)
+		}
+	}
+	return fromSorted(elements)
+}
+
+// Partition returns two new SortedSets, the first containing the elements
+// of this SortedSet for which pred returns true, and the second containing
+// the others. For example:
+//
+//	evens, odds := sset.Partition(func(x int) bool { return x%2 == 0 })
+//
+// See also [SortedSet.Filter].
+func (me *SortedSet[E]) Partition(pred func(E) bool) (yes, no SortedSet[E]) {
+	var yesElements, noElements []E
+	for element := range me.All() {
+		if pred(element) {
+			yesElements = append(yesElements, element)
+		} else {
+			noElements = append(noElements, element)
+		}
+	}
+	return fromSorted(yesElements), fromSorted(noElements)
+}
+
+// SplitAt returns two new SortedSets, the first containing this
+// SortedSet's elements with sorted indexes (counting from 0) less than i,
+// and the second containing the rest. If i <= 0 the first is empty, and if
+// i >= Len() the second is empty. This SortedSet is unchanged. For
+// example:
+//
+//	firstHalf, secondHalf := sset.SplitAt(sset.Len() / 2)
+//
+// See also [SortedSet.SplitByKey].
+func (me *SortedSet[E]) SplitAt(i int) (left, right SortedSet[E]) {
+	elements := me.ToSlice()
+	i = max(0, min(i, len(elements)))
+	return fromSorted(elements[:i]), fromSorted(elements[i:])
+}
+
+// SplitByKey returns two new SortedSets, the first containing this
+// SortedSet's elements that are less than x, and the second containing
+// those that are greater than or equal to x, and also returns true if x
+// itself is in this SortedSet. This SortedSet is unchanged. For example:
+//
+//	less, greaterEqual, hadX := sset.SplitByKey(x)
+//
+// See also [SortedSet.SplitAt], [SortedSet.HeadSet], and
+// [SortedSet.TailSet].
+func (me *SortedSet[E]) SplitByKey(x E) (less, greaterEqual SortedSet[E],
+	hadX bool,
+) {
+	elements := me.ToSlice()
+	i, hadX := slices.BinarySearch(elements, x)
+	return fromSorted(elements[:i]), fromSorted(elements[i:]), hadX
+}
+
+// Concat returns a new SortedSet containing all the elements of left and
+// right, and true, providing every element of left is less than every
+// element of right; otherwise it returns an empty SortedSet and false.
+// The two trees are joined in O(log n) time, with the result sharing their
+// nodes as if it was a snapshot of both (see [SortedSet.Snapshot]), so
+// changing the result never affects left or right, or vice versa. Like
+// Snapshot, Concat counts as a change to left and right, so must not be
+// called while another goroutine is reading either of them. For example:
+//
+//	all, ok := Concat(&lows, &highs)
+//
+// See also [SortedSet.SplitByKey] and [SortedSet.Absorb].
+func Concat[E Comparable](left, right *SortedSet[E]) (SortedSet[E], bool) {
+	if !left.IsEmpty() && !right.IsEmpty() &&
+		!(last(left.root).element < first(right.root).element) {
+		return SortedSet[E]{}, false
+	}
+	lows, highs := left.Snapshot(), right.Snapshot()
+	if lows.IsEmpty() {
+		return highs, true
+	}
+	if highs.IsEmpty() {
+		return lows, true
+	}
+	var zero E
+	pivot, _ := highs.remove(zero, 0, deleteMin) // copies the path
+	highs.root = join(highs.cow, lows.root, highs.newNode(pivot), highs.root)
+	highs.size += lows.size + 1
+	return highs, true
+}
+
+// AnyMatch returns true if pred returns true for at least one of the
+// SortedSet's elements (stopping at the first such element); otherwise
+// returns false.
+// See also [SortedSet.AllMatch].
+func (me *SortedSet[E]) AnyMatch(pred func(E) bool) bool {
+	for element := range me.All() {
+		if pred(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllMatch returns true if pred returns true for every one of the
+// SortedSet's elements (or if the SortedSet is empty); otherwise returns
+// false (stopping at the first element for which pred returns false).
+// See also [SortedSet.AnyMatch].
+func (me *SortedSet[E]) AllMatch(pred func(E) bool) bool {
+	for element := range me.All() {
+		if !pred(element) {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of the SortedSet's elements for which pred
+// returns true.
+// See also [SortedSet.Len].
+func (me *SortedSet[E]) Count(pred func(E) bool) int {
+	count := 0
+	for element := range me.All() {
+		if pred(element) {
+			count++
+		}
+	}
+	return count
+}
+
+// Reduce returns the result of applying f to an accumulator (initially
+// init) and each of the SortedSet's elements in ascending order, i.e., a
+// left fold. For example:
+//
+//	total := Reduce(sset, 0, func(total, x int) int { return total + x })
+func Reduce[E Comparable, A any](sset SortedSet[E], init A,
+	f func(A, E) A,
+) A {
+	accumulator := init
+	for element := range sset.All() {
+		accumulator = f(accumulator, element)
+	}
+	return accumulator
+}
+
+// Map returns a new SortedSet containing the result of applying f to each
+// of the given SortedSet's elements. If f maps two or more elements to the
+// same result it is only included once. For example:
+//
+//	lengths := Map(words, func(word string) int { return len(word) })
+func Map[E, F Comparable](sset SortedSet[E], f func(E) F) SortedSet[F] {
+	elements := make([]F, 0, sset.Len())
+	for element := range sset.All() {
+		elements = append(elements, f(element))
+	}
+	slices.Sort(elements)
+	return fromSorted(slices.Compact(elements))
+}
+
+// MinBy returns the element of the given SortedSet for which key returns
+// the smallest value, and true; or the zero value and false if the
+// SortedSet is empty. If several elements have the smallest key, the first
+// (i.e., smallest) of them is returned. Since the key order may differ
+// from the element order every element is checked. For example:
+//
+//	shortest, ok := MinBy(words, func(word string) int { return len(word) })
+//
+// See also [MaxBy] and [SortedSet.Min].
+func MinBy[E, K Comparable](sset SortedSet[E], key func(E) K) (E, bool) {
+	return extremeBy(sset, key, func(a, b K) bool { return a < b })
+}
+
+// MaxBy returns the element of the given SortedSet for which key returns
+// the largest value, and true; or the zero value and false if the
+// SortedSet is empty. If several elements have the largest key, the first
+// (i.e., smallest) of them is returned. Since the key order may differ
+// from the element order every element is checked.
+// See also [MinBy] and [SortedSet.Max].
+func MaxBy[E, K Comparable](sset SortedSet[E], key func(E) K) (E, bool) {
+	return extremeBy(sset, key, func(a, b K) bool { return b < a })
+}
+
+// extremeBy returns the first element whose key is better than every
+// other element's key.
+func extremeBy[E, K Comparable](sset SortedSet[E], key func(E) K,
+	better func(a, b K) bool,
+) (E, bool) {
+	var best E
+	var bestKey K
+	found := false
+	for element := range sset.All() {
+		if k := key(element); !found || better(k, bestKey) {
+			best, bestKey, found = element, k, true
+		}
+	}
+	return best, found
+}
+
+// HeadSet returns a new SortedSet that contains this SortedSet's elements
+// that are less than toElement.
+// See also [SortedSet.TailSet] and [SortedSet.SubSet].
+func (me *SortedSet[E]) HeadSet(toElement E) SortedSet[E] {
+	return me.rangeSet(nil, &toElement)
+}
+
+// TailSet returns a new SortedSet that contains this SortedSet's elements
+// that are greater than or equal to fromElement.
+// See also [SortedSet.HeadSet] and [SortedSet.SubSet].
+func (me *SortedSet[E]) TailSet(fromElement E) SortedSet[E] {
+	return me.rangeSet(&fromElement, nil)
+}
+
+// SubSet returns a new SortedSet that contains this SortedSet's elements
+// that are greater than or equal to from and less than to; the new
+// SortedSet is empty if from > to.
+// See also [SortedSet.HeadSet], [SortedSet.TailSet], and
+// [SortedSet.Between].
+func (me *SortedSet[E]) SubSet(from, to E) SortedSet[E] {
+	if to < from {
+		return SortedSet[E]{}
+	}
+	return me.rangeSet(&from, &to)
+}
+
+func (me *SortedSet[E]) rangeSet(lo, hi *E) SortedSet[E] {
+	var elements []E
+	between(me.root, lo, hi, func(element E) bool {
+		elements = append(elements, element)
+		return true
+	})
+	return fromSorted(elements)
+}
+
 // Clone returns a copy of this SortedSet.
 func (me *SortedSet[E]) Clone() SortedSet[E] {
 	clone := SortedSet[E]{}
@@ -411,38 +2328,173 @@ func (me *SortedSet[E]) Clone() SortedSet[E] {
 	return clone
 }
 
+// Snapshot returns a copy of this SortedSet in O(1) time. The copy and
+// this SortedSet initially share all their nodes; thereafter, whenever
+// either is changed (e.g., by [SortedSet.Add] or [SortedSet.Delete]), only
+// the nodes on the path that is changed are copied. So changing one never
+// affects the other. Snapshot is ideal for keeping cheap versions of a
+// large SortedSet; use [SortedSet.Clone] to get a completely independent
+// copy. Note that Snapshot counts as a change to this SortedSet, so must
+// not be called while another goroutine is reading it.
+func (me *SortedSet[E]) Snapshot() SortedSet[E] {
+	snapshot := *me
+	snapshot.alloc, snapshot.owner = nil, false // the spare nodes stay
+	me.cow = new(cowToken)
+	snapshot.cow = new(cowToken)
+	return snapshot
+}
+
 // ToSlice returns this SortedSet's elements as a sorted slice.
 // For iteration either use this, or if you only need one value at a time,
 // use [All] or [AllX].
 func (me *SortedSet[E]) ToSlice() []E {
-	slice := make([]E, 0, me.Len())
+	return me.AppendTo(make([]E, 0, me.Len()))
+}
+
+// AppendTo appends this SortedSet's elements in ascending order to dst
+// (growing it if necessary) and returns the extended slice, like the
+// built-in append. This avoids allocating if dst has enough capacity, so
+// is useful when the same buffer is reused many times. For example:
+//
+//	buffer = sset.AppendTo(buffer[:0])
+//
+// See also [SortedSet.ToSlice].
+func (me *SortedSet[E]) AppendTo(dst []E) []E {
+	dst = slices.Grow(dst, me.Len())
 	for element := range me.All() {
+		dst = append(dst, element)
+	}
+	return dst
+}
+
+// ToSliceRange returns the SortedSet's elements whose sorted indexes
+// (counting from 0) are in the range [start, end), as a sorted slice. The
+// range is clipped to [0, Len()), so the slice is empty if start >= end.
+// Only the requested elements are visited, so this is much cheaper than
+// reslicing [SortedSet.ToSlice] when the range is small. For example:
+//
+//	rows := sset.ToSliceRange(100, 150)
+func (me *SortedSet[E]) ToSliceRange(start, end int) []E {
+	start, end = max(start, 0), min(end, me.Len())
+	if start >= end {
+		return []E{}
+	}
+	slice := make([]E, 0, end-start)
+	walker := newWalkerAt(me.root, start)
+	for range end - start {
+		element, _ := walker.next()
 		slice = append(slice, element)
 	}
 	return slice
 }
 
+// Page returns up to limit of the SortedSet's elements as a sorted slice,
+// skipping the first offset elements, so is ideal for pagination. The
+// slice has fewer than limit elements if the page is the last one, and is
+// empty if offset >= Len() or limit <= 0; a negative offset is treated as
+// 0. Page seeks straight to the offset in O(log n) time. For example:
+//
+//	rows := sset.Page(pageNumber*pageSize, pageSize)
+//
+// See also [SortedSet.ToSliceRange].
+func (me *SortedSet[E]) Page(offset, limit int) []E {
+	offset = max(offset, 0)
+	if limit <= 0 || offset >= me.Len() {
+		return []E{}
+	}
+	return me.ToSliceRange(offset, offset+min(limit, me.Len()-offset))
+}
+
+// Drain returns this SortedSet's elements as a sorted slice and clears the
+// SortedSet, leaving it empty and ready for reuse. For example:
+//
+//	results := sset.Drain()
+//
+// See also [SortedSet.ToSlice] and [SortedSet.Clear].
+func (me *SortedSet[E]) Drain() []E {
+	slice := me.ToSlice()
+	me.Clear()
+	return slice
+}
+
 // String returns a human readable string representation of the SortedSet.
 func (me *SortedSet[E]) String() string {
+	return "{" + me.Join(" ") + "}"
+}
+
+// Join returns the SortedSet's elements in ascending order separated by
+// sep, with string elements quoted as they are by [SortedSet.String].
+// For example, New(1, 2, 3).Join(",") returns "1,2,3".
+func (me *SortedSet[E]) Join(sep string) string {
 	format := "%s%v"
-	if me.hasStringElements() {
+	if isString[E]() {
 		format = "%s%q"
 	}
 	var out strings.Builder
-	out.WriteByte('{')
+	separator := ""
+	for element := range me.All() {
+		fmt.Fprintf(&out, format, separator, element)
+		separator = sep
+	}
+	return out.String()
+}
+
+// GoString returns a Go syntax representation of the SortedSet, e.g.,
+// sortedset.New(1, 2, 3). This is used for the %#v format.
+func (me SortedSet[E]) GoString() string {
+	var out strings.Builder
+	out.WriteString("sortedset.New")
+	var zero E
+	switch any(zero).(type) {
+	case int, string: // element types that can be inferred from literals
+		if me.IsEmpty() {
+			fmt.Fprintf(&out, "[%T]", zero)
+		}
+	default:
+		fmt.Fprintf(&out, "[%T]", zero)
+	}
+	out.WriteByte('(')
 	sep := ""
-	for _, element := range me.ToSlice() {
-		fmt.Fprintf(&out, format, sep, element)
-		sep = " "
+	for element := range me.All() {
+		fmt.Fprintf(&out, "%s%#v", sep, element)
+		sep = ", "
 	}
-	out.WriteByte('}')
+	out.WriteByte(')')
 	return out.String()
 }
 
-func (me *SortedSet[E]) hasStringElements() bool {
+// Format implements [fmt.Formatter] by applying the verb, flags, width,
+// and precision to each element, while keeping the {...} framing used by
+// [SortedSet.String]. For example, fmt.Sprintf("%x", New(10, 255)) returns
+// "{a ff}" and fmt.Sprintf("%3d", New(1, 2)) returns "{  1   2}". The %v
+// and %s verbs format the elements as String does (i.e., with string
+// elements quoted), and %#v uses [SortedSet.GoString].
+func (me SortedSet[E]) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, me.GoString())
+		return
+	}
+	if verb == 'v' || verb == 's' {
+		verb = 'v'
+		if isString[E]() {
+			verb = 'q'
+		}
+	}
+	format := fmt.FormatString(f, verb)
+	io.WriteString(f, "{")
+	sep := ""
 	for element := range me.All() {
-		_, ok := any(element).(string)
-		return ok
+		io.WriteString(f, sep)
+		fmt.Fprintf(f, format, element)
+		sep = " "
 	}
-	return false
+	io.WriteString(f, "}")
+}
+
+// isString returns true if E is string. This depends only on the type so
+// is cheap, unlike checking an element (which requires a tree descent).
+func isString[E any]() bool {
+	var zero E
+	_, ok := any(zero).(string)
+	return ok
 }
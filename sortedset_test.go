@@ -3,6 +3,9 @@ package sortedset
 
 import (
 	"fmt"
+	"maps"
+	"math"
+	"math/rand"
 	"slices"
 	"strings"
 	"testing"
@@ -340,6 +343,42 @@ func TestIsSubsetOf(t *testing.T) {
 	}
 }
 
+func TestIsSubsetOfRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 500 {
+		var s, u SortedSet[int]
+		for range rng.Intn(20) {
+			s.Add(rng.Intn(40))
+		}
+		for range rng.Intn(200) { // sometimes much bigger than s
+			u.Add(rng.Intn(40 + rng.Intn(400)))
+		}
+		if rng.Intn(2) == 0 {
+			u.Unite(s)
+		}
+		expected := true
+		for element := range s.All() {
+			if !u.Contains(element) {
+				expected = false
+				break
+			}
+		}
+		if s.IsSubsetOf(u) != expected {
+			t.Fatalf("%v IsSubsetOf %v: expected %t", s, u, expected)
+		}
+	}
+}
+
+func BenchmarkIsSubsetOf(b *testing.B) {
+	s, _ := makeEqualSets()
+	u := s.Clone()
+	u.Add(-1)
+	b.ResetTimer()
+	for range b.N {
+		s.IsSubsetOf(u)
+	}
+}
+
 func TestIsSupersetOf(t *testing.T) {
 	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
 	u := s.Clone()
@@ -377,6 +416,95 @@ func TestToSlice(t *testing.T) {
 	check(fmt.Sprintf("%v", u), len(u), "[1 2 4 8 19 21]", s.Len(), t)
 }
 
+func TestAppendTo(t *testing.T) {
+	s := New(19, 21, 1, 2, 4, 8)
+	u := s.AppendTo([]int{-1, 0})
+	check(fmt.Sprintf("%v", u), len(u), "[-1 0 1 2 4 8 19 21]", 8, t)
+	var empty SortedSet[int]
+	u = empty.AppendTo(u[:1])
+	check(fmt.Sprintf("%v", u), len(u), "[-1]", 1, t)
+	buffer := make([]int, 0, s.Len())
+	allocs := testing.AllocsPerRun(10, func() {
+		buffer = s.AppendTo(buffer[:0])
+	})
+	if allocs > 0 {
+		t.Errorf("expected no allocations, got %g", allocs)
+	}
+	check(fmt.Sprintf("%v", buffer), len(buffer), "[1 2 4 8 19 21]", 6, t)
+}
+
+func TestToSliceRange(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		start, end int
+		expected   string
+	}{
+		{0, 5, "[10 20 30 40 50]"},
+		{1, 3, "[20 30]"},
+		{4, 5, "[50]"},
+		{-2, 2, "[10 20]"},
+		{3, 99, "[40 50]"},
+		{2, 2, "[]"},
+		{3, 1, "[]"},
+		{5, 9, "[]"},
+	} {
+		slice := s.ToSliceRange(datum.start, datum.end)
+		if actual := fmt.Sprint(slice); actual != datum.expected {
+			t.Errorf("ToSliceRange(%d, %d): expected %s, got %s",
+				datum.start, datum.end, datum.expected, actual)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	s = FromSlice(rng.Perm(1000))
+	all := s.ToSlice()
+	for range 100 {
+		start := rng.Intn(1000)
+		end := start + rng.Intn(1000-start+1)
+		if !slices.Equal(s.ToSliceRange(start, end), all[start:end]) {
+			t.Fatalf("ToSliceRange(%d, %d): wrong elements", start, end)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, 6, 7)
+	for _, datum := range []struct {
+		offset, limit int
+		exp           []int
+	}{
+		{0, 3, []int{1, 2, 3}},
+		{3, 3, []int{4, 5, 6}},
+		{6, 3, []int{7}},
+		{7, 3, []int{}},
+		{99, 3, []int{}},
+		{-2, 2, []int{1, 2}},
+		{2, 0, []int{}},
+		{2, -1, []int{}},
+		{5, math.MaxInt, []int{6, 7}},
+	} {
+		page := s.Page(datum.offset, datum.limit)
+		if page == nil || !slices.Equal(page, datum.exp) {
+			t.Errorf("Page(%d, %d): expected %v, got %v", datum.offset,
+				datum.limit, datum.exp, page)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	s := New(19, 21, 1, 2, 4, 8)
+	u := s.Snapshot()
+	slice := s.Drain()
+	check(fmt.Sprintf("%v", slice), len(slice), "[1 2 4 8 19 21]", 6, t)
+	check(s.String(), s.Len(), "{}", 0, t)
+	check(u.String(), u.Len(), "{1 2 4 8 19 21}", 6, t)
+	s.Add(3)
+	check(s.String(), s.Len(), "{3}", 1, t)
+	var empty SortedSet[int]
+	if slice = empty.Drain(); len(slice) != 0 {
+		t.Errorf("expected empty slice, got %v", slice)
+	}
+}
+
 func TestAll(t *testing.T) {
 	s := New(10, 20, 30, 40, 50, 60, 70, 80, 90)
 	n := 0
@@ -414,3 +542,2182 @@ func check(act string, actSize int, exp string, expSize int, t *testing.T) {
 		t.Errorf("expected %s, got %s", exp, act)
 	}
 }
+
+func TestMinMax(t *testing.T) {
+	var s SortedSet[int]
+	if x, ok := s.Min(); ok || x != 0 {
+		t.Errorf("expected 0 false, got %d %t", x, ok)
+	}
+	if x, ok := s.Max(); ok || x != 0 {
+		t.Errorf("expected 0 false, got %d %t", x, ok)
+	}
+	s = New(19, 21, 1, 2, 4, 8)
+	if x, ok := s.Min(); !ok || x != 1 {
+		t.Errorf("expected 1 true, got %d %t", x, ok)
+	}
+	if x, ok := s.Max(); !ok || x != 21 {
+		t.Errorf("expected 21 true, got %d %t", x, ok)
+	}
+	u := New("one")
+	if x, ok := u.Min(); !ok || x != "one" {
+		t.Errorf("expected \"one\" true, got %q %t", x, ok)
+	}
+	if x, ok := u.Max(); !ok || x != "one" {
+		t.Errorf("expected \"one\" true, got %q %t", x, ok)
+	}
+}
+
+func TestContainsSorted(t *testing.T) {
+	var s SortedSet[int]
+	if found := s.ContainsSorted([]int{1, 2}); !slices.Equal(found,
+		[]bool{false, false}) {
+		t.Errorf("expected [false false], got %v", found)
+	}
+	for i := 0; i < 100; i += 2 {
+		s.Add(i)
+	}
+	for _, probes := range [][]int{
+		{}, {-1}, {0}, {50}, {51}, {98}, {99}, {-5, 0, 0, 3, 4, 4, 98, 200},
+		{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+	} {
+		found := s.ContainsSorted(probes)
+		if len(found) != len(probes) {
+			t.Fatalf("expected %d results, got %d", len(probes), len(found))
+		}
+		for i, probe := range probes {
+			if found[i] != s.Contains(probe) {
+				t.Errorf("ContainsSorted(%v)[%d]: expected %t", probes, i,
+					s.Contains(probe))
+			}
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	s := New("alpha", "beta")
+	if x, ok := s.Get(strings.Clone("beta")); !ok || x != "beta" {
+		t.Errorf("expected \"beta\" true, got %q %t", x, ok)
+	}
+	if x, ok := s.Get("gamma"); ok || x != "" {
+		t.Errorf("expected \"\" false, got %q %t", x, ok)
+	}
+	var u SortedSet[int]
+	if x, ok := u.Get(1); ok {
+		t.Errorf("unexpected element in empty set: %d", x)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	s := New(10, 20, 30)
+	u := s.Snapshot()
+	if old, ok := s.Replace(20); !ok || old != 20 {
+		t.Errorf("expected 20 true, got %d %t", old, ok)
+	}
+	if old, ok := s.Replace(25); ok || old != 0 {
+		t.Errorf("expected 0 false, got %d %t", old, ok)
+	}
+	checkLenAndOrder(&s, 4, t)
+	check(s.String(), s.Len(), "{10 20 25 30}", 4, t)
+	check(u.String(), u.Len(), "{10 20 30}", 3, t)
+	if u.root == s.root {
+		t.Error("expected the snapshot's nodes to be copied")
+	}
+}
+
+func TestFirstLast(t *testing.T) {
+	var s SortedSet[string]
+	if x, ok := s.First(); ok || x != "" {
+		t.Errorf("expected \"\" false, got %q %t", x, ok)
+	}
+	if x, ok := s.Last(); ok || x != "" {
+		t.Errorf("expected \"\" false, got %q %t", x, ok)
+	}
+	s = New("m", "b", "x", "d")
+	if x, ok := s.First(); !ok || x != "b" {
+		t.Errorf("expected \"b\" true, got %q %t", x, ok)
+	}
+	if x, ok := s.Last(); !ok || x != "x" {
+		t.Errorf("expected \"x\" true, got %q %t", x, ok)
+	}
+}
+
+func TestFloorCeiling(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		x, floor, ceiling    int
+		hasFloor, hasCeiling bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 10, 10, true, true},
+		{25, 20, 30, true, true},
+		{50, 50, 50, true, true},
+		{55, 50, 0, true, false},
+	} {
+		floor, ok := s.Floor(datum.x)
+		if floor != datum.floor || ok != datum.hasFloor {
+			t.Errorf("Floor(%d): expected %d %t, got %d %t", datum.x,
+				datum.floor, datum.hasFloor, floor, ok)
+		}
+		ceiling, ok := s.Ceiling(datum.x)
+		if ceiling != datum.ceiling || ok != datum.hasCeiling {
+			t.Errorf("Ceiling(%d): expected %d %t, got %d %t", datum.x,
+				datum.ceiling, datum.hasCeiling, ceiling, ok)
+		}
+	}
+	var u SortedSet[int]
+	if _, ok := u.Floor(1); ok {
+		t.Error("unexpected floor in empty set")
+	}
+	if _, ok := u.Ceiling(1); ok {
+		t.Error("unexpected ceiling in empty set")
+	}
+}
+
+func TestLowerHigher(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		x, lower, higher    int
+		hasLower, hasHigher bool
+	}{
+		{5, 0, 10, false, true},
+		{10, 0, 20, false, true},
+		{25, 20, 30, true, true},
+		{30, 20, 40, true, true},
+		{50, 40, 0, true, false},
+		{55, 50, 0, true, false},
+	} {
+		lower, ok := s.Lower(datum.x)
+		if lower != datum.lower || ok != datum.hasLower {
+			t.Errorf("Lower(%d): expected %d %t, got %d %t", datum.x,
+				datum.lower, datum.hasLower, lower, ok)
+		}
+		higher, ok := s.Higher(datum.x)
+		if higher != datum.higher || ok != datum.hasHigher {
+			t.Errorf("Higher(%d): expected %d %t, got %d %t", datum.x,
+				datum.higher, datum.hasHigher, higher, ok)
+		}
+	}
+	var u SortedSet[int]
+	if _, ok := u.Lower(1); ok {
+		t.Error("unexpected lower in empty set")
+	}
+	if _, ok := u.Higher(1); ok {
+		t.Error("unexpected higher in empty set")
+	}
+}
+
+func TestAddAndNeighbors(t *testing.T) {
+	var s SortedSet[int]
+	for _, datum := range []struct {
+		x, prev, next    int
+		hasPrev, hasNext bool
+	}{
+		{50, 0, 0, false, false},
+		{30, 0, 50, false, true},
+		{40, 30, 50, true, true},
+		{60, 50, 0, true, false},
+		{40, 30, 50, true, true}, // already present
+		{10, 0, 30, false, true},
+		{30, 10, 40, true, true}, // already present
+		{45, 40, 50, true, true},
+	} {
+		prev, hasPrev, next, hasNext := s.AddAndNeighbors(datum.x)
+		if prev != datum.prev || hasPrev != datum.hasPrev ||
+			next != datum.next || hasNext != datum.hasNext {
+			t.Errorf("AddAndNeighbors(%d): expected %d %t %d %t, got %d %t "+
+				"%d %t", datum.x, datum.prev, datum.hasPrev, datum.next,
+				datum.hasNext, prev, hasPrev, next, hasNext)
+		}
+	}
+	checkLenAndOrder(&s, 6, t)
+	check(s.String(), s.Len(), "{10 30 40 45 50 60}", 6, t)
+}
+
+func TestAt(t *testing.T) {
+	var s SortedSet[int]
+	if _, ok := s.At(0); ok {
+		t.Error("unexpected element in empty set")
+	}
+	for _, number := range []int{9, 1, 8, 2, 7, 3, 6, 4, 5, 0} {
+		s.Add(number * 10)
+	}
+	for _, number := range []int{0, 50, 90, 30} {
+		s.Delete(number)
+	}
+	for i, expected := range s.ToSlice() {
+		if x, ok := s.At(i); !ok || x != expected {
+			t.Errorf("At(%d): expected %d true, got %d %t", i, expected, x,
+				ok)
+		}
+	}
+	for _, i := range []int{-1, 6, 10} {
+		if x, ok := s.At(i); ok {
+			t.Errorf("At(%d): expected false, got %d %t", i, x, ok)
+		}
+	}
+}
+
+func TestNthSmallestLargest(t *testing.T) {
+	s := New(50, 10, 40, 20, 30)
+	for n, expected := range []int{10, 20, 30, 40, 50} {
+		if x, ok := s.NthSmallest(n + 1); !ok || x != expected {
+			t.Errorf("NthSmallest(%d): expected %d true, got %d %t", n+1,
+				expected, x, ok)
+		}
+	}
+	for n, expected := range []int{50, 40, 30, 20, 10} {
+		if x, ok := s.NthLargest(n + 1); !ok || x != expected {
+			t.Errorf("NthLargest(%d): expected %d true, got %d %t", n+1,
+				expected, x, ok)
+		}
+	}
+	for _, n := range []int{-1, 0, 6} {
+		if x, ok := s.NthSmallest(n); ok {
+			t.Errorf("NthSmallest(%d): expected false, got %d %t", n, x, ok)
+		}
+		if x, ok := s.NthLargest(n); ok {
+			t.Errorf("NthLargest(%d): expected false, got %d %t", n, x, ok)
+		}
+	}
+}
+
+func TestRandomAddDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var s SortedSet[int]
+	m := map[int]bool{}
+	for range 20000 {
+		x := rng.Intn(300)
+		if rng.Intn(2) == 0 {
+			if s.Add(x) == m[x] {
+				t.Fatalf("Add(%d) returned wrong result", x)
+			}
+			m[x] = true
+		} else {
+			if s.Delete(x) != m[x] {
+				t.Fatalf("Delete(%d) returned wrong result", x)
+			}
+			delete(m, x)
+		}
+		if s.Len() != len(m) || sizeOf(s.root) != len(m) {
+			t.Fatalf("expected %d elements, got %d (tree has %d)", len(m),
+				s.Len(), sizeOf(s.root))
+		}
+	}
+	i := 0
+	for element := range s.All() {
+		if !m[element] {
+			t.Errorf("unexpected element %d", element)
+		}
+		if x, ok := s.At(i); !ok || x != element {
+			t.Errorf("At(%d): expected %d true, got %d %t", i, element, x,
+				ok)
+		}
+		i++
+	}
+}
+
+func TestPopAt(t *testing.T) {
+	s := New(0, 10, 20, 30, 40, 50)
+	if x, ok := s.PopAt(2); !ok || x != 20 {
+		t.Errorf("expected 20 true, got %d %t", x, ok)
+	}
+	if x, ok := s.PopAt(0); !ok || x != 0 {
+		t.Errorf("expected 0 true, got %d %t", x, ok)
+	}
+	for _, i := range []int{-1, 4} {
+		if x, ok := s.PopAt(i); ok {
+			t.Errorf("PopAt(%d): expected false, got %d %t", i, x, ok)
+		}
+	}
+	checkLenAndOrder(&s, 4, t)
+	check(s.String(), s.Len(), "{10 30 40 50}", 4, t)
+	rng := rand.New(rand.NewSource(1))
+	s = FromSlice(rng.Perm(500))
+	expected := s.ToSlice()
+	for s.Len() > 0 {
+		i := rng.Intn(s.Len())
+		x, ok := s.PopAt(i)
+		if !ok || x != expected[i] {
+			t.Fatalf("PopAt(%d): expected %d true, got %d %t", i,
+				expected[i], x, ok)
+		}
+		expected = slices.Delete(expected, i, i+1)
+		checkLenAndOrder(&s, len(expected), t)
+	}
+}
+
+func TestSample(t *testing.T) {
+	s := FromSlice(rand.New(rand.NewSource(1)).Perm(100))
+	rng := rand.New(rand.NewSource(1))
+	counts := make([]int, s.Len())
+	for range 2000 {
+		sample := s.Sample(10, rng)
+		if len(sample) != 10 || !slices.IsSorted(sample) ||
+			len(slices.Compact(slices.Clone(sample))) != 10 {
+			t.Fatalf("expected 10 distinct sorted elements, got %v", sample)
+		}
+		for _, element := range sample {
+			counts[element]++
+		}
+	}
+	for element, count := range counts { // expect about 200 each
+		if count < 100 || count > 300 {
+			t.Errorf("element %d chosen %d times", element, count)
+		}
+	}
+	if sample := s.Sample(5, nil); len(sample) != 5 {
+		t.Errorf("expected 5 elements, got %v", sample)
+	}
+	u := New(3, 1, 2)
+	for _, k := range []int{3, 4} {
+		if sample := u.Sample(k, rng); fmt.Sprint(sample) != "[1 2 3]" {
+			t.Errorf("Sample(%d): expected [1 2 3], got %v", k, sample)
+		}
+	}
+	for _, k := range []int{0, -1} {
+		if sample := u.Sample(k, rng); len(sample) != 0 {
+			t.Errorf("Sample(%d): expected [], got %v", k, sample)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	var s SortedSet[int]
+	if rank := s.Rank(5); rank != 0 {
+		t.Errorf("expected 0, got %d", rank)
+	}
+	s = New(10, 20, 30, 40, 50)
+	for _, datum := range []struct{ x, rank int }{
+		{5, 0}, {10, 0}, {15, 1}, {20, 1}, {30, 2}, {45, 4}, {50, 4},
+		{55, 5},
+	} {
+		if rank := s.Rank(datum.x); rank != datum.rank {
+			t.Errorf("Rank(%d): expected %d, got %d", datum.x, datum.rank,
+				rank)
+		}
+	}
+}
+
+func TestCountBetween(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct{ lo, hi, count int }{
+		{0, 100, 5}, {10, 50, 4}, {10, 51, 5}, {15, 35, 2}, {20, 20, 0},
+		{30, 20, 0}, {60, 70, 0},
+	} {
+		if count := s.CountBetween(datum.lo, datum.hi); count != datum.count {
+			t.Errorf("CountBetween(%d, %d): expected %d, got %d", datum.lo,
+				datum.hi, datum.count, count)
+		}
+	}
+}
+
+func TestRemoveRange(t *testing.T) {
+	s := New(10, 20, 30, 40, 50, 60)
+	if count := s.RemoveRange(40, 40); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+	if count := s.RemoveRange(50, 20); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+	if count := s.RemoveRange(15, 40); count != 2 {
+		t.Errorf("expected 2, got %d", count)
+	}
+	checkLenAndOrder(&s, 4, t)
+	check(s.String(), s.Len(), "{10 40 50 60}", 4, t)
+	rng := rand.New(rand.NewSource(1))
+	for range 200 {
+		s = FromSlice(rng.Perm(1000))
+		u := s.Snapshot()
+		lo := rng.Intn(1000)
+		hi := lo + rng.Intn(1000-lo+1)
+		count := s.RemoveRange(lo, hi)
+		if count != hi-lo {
+			t.Fatalf("RemoveRange(%d, %d): expected %d, got %d", lo, hi,
+				hi-lo, count)
+		}
+		checkLenAndOrder(&s, 1000-count, t)
+		if s.CountBetween(lo, hi) != 0 || (lo > 0 && !s.Contains(lo-1)) ||
+			(hi < 1000 && !s.Contains(hi)) {
+			t.Fatalf("RemoveRange(%d, %d): wrong elements removed", lo, hi)
+		}
+		checkLenAndOrder(&u, 1000, t) // unaffected snapshot
+	}
+}
+
+func TestKeepSmallest(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		k, count int
+		exp      string
+		size     int
+	}{
+		{9, 0, "{10 20 30 40 50}", 5},
+		{5, 0, "{10 20 30 40 50}", 5},
+		{3, 2, "{10 20 30}", 3},
+		{2, 1, "{10 20}", 2},
+		{0, 2, "{}", 0},
+		{-1, 0, "{}", 0},
+	} {
+		if count := s.KeepSmallest(datum.k); count != datum.count {
+			t.Errorf("KeepSmallest(%d): expected %d, got %d", datum.k,
+				datum.count, count)
+		}
+		check(s.String(), s.Len(), datum.exp, datum.size, t)
+	}
+	s = New(1, 2, 3)
+	if count := s.KeepSmallest(-5); count != 3 {
+		t.Errorf("expected 3, got %d", count)
+	}
+	check(s.String(), s.Len(), "{}", 0, t)
+	rng := rand.New(rand.NewSource(1))
+	for _, k := range []int{1, 100, 500, 990, 999} {
+		s = FromSlice(rng.Perm(1000))
+		u := s.Snapshot()
+		if count := s.KeepSmallest(k); count != 1000-k {
+			t.Fatalf("KeepSmallest(%d): expected %d, got %d", k, 1000-k,
+				count)
+		}
+		checkLenAndOrder(&s, k, t)
+		if x, _ := s.Max(); x != k-1 {
+			t.Fatalf("KeepSmallest(%d): expected max %d, got %d", k, k-1, x)
+		}
+		checkLenAndOrder(&u, 1000, t) // unaffected snapshot
+	}
+}
+
+func TestKeepLargest(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		k, count int
+		exp      string
+		size     int
+	}{
+		{9, 0, "{10 20 30 40 50}", 5},
+		{5, 0, "{10 20 30 40 50}", 5},
+		{3, 2, "{30 40 50}", 3},
+		{2, 1, "{40 50}", 2},
+		{-1, 2, "{}", 0},
+		{0, 0, "{}", 0},
+	} {
+		if count := s.KeepLargest(datum.k); count != datum.count {
+			t.Errorf("KeepLargest(%d): expected %d, got %d", datum.k,
+				datum.count, count)
+		}
+		check(s.String(), s.Len(), datum.exp, datum.size, t)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, k := range []int{1, 100, 500, 990, 999} {
+		s = FromSlice(rng.Perm(1000))
+		u := s.Snapshot()
+		if count := s.KeepLargest(k); count != 1000-k {
+			t.Fatalf("KeepLargest(%d): expected %d, got %d", k, 1000-k,
+				count)
+		}
+		checkLenAndOrder(&s, k, t)
+		if x, _ := s.Min(); x != 1000-k {
+			t.Fatalf("KeepLargest(%d): expected min %d, got %d", k, 1000-k,
+				x)
+		}
+		checkLenAndOrder(&u, 1000, t) // unaffected snapshot
+	}
+}
+
+func TestCutRange(t *testing.T) {
+	s := New(10, 20, 30, 40, 50, 60)
+	u := s.CutRange(15, 45)
+	checkLenAndOrder(&s, 3, t)
+	checkLenAndOrder(&u, 3, t)
+	check(s.String(), s.Len(), "{10 50 60}", 3, t)
+	check(u.String(), u.Len(), "{20 30 40}", 3, t)
+	for _, bounds := range [][2]int{{45, 15}, {30, 30}, {20, 50}} {
+		u = s.CutRange(bounds[0], bounds[1])
+		check(u.String(), u.Len(), "{}", 0, t)
+	}
+	check(s.String(), s.Len(), "{10 50 60}", 3, t)
+	u = s.CutRange(0, 100)
+	check(s.String(), s.Len(), "{}", 0, t)
+	check(u.String(), u.Len(), "{10 50 60}", 3, t)
+}
+
+func TestCoversRange(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, 7, 8, 9)
+	for _, datum := range []struct {
+		lo, hi   int
+		expected bool
+	}{
+		{1, 5, true},
+		{2, 4, true},
+		{3, 3, true},
+		{6, 6, false},
+		{4, 8, false},
+		{7, 9, true},
+		{0, 2, false},
+		{8, 10, false},
+		{1, 9, false},
+		{5, 4, true}, // empty range
+	} {
+		if ok := CoversRange(s, datum.lo, datum.hi); ok != datum.expected {
+			t.Errorf("CoversRange(%d, %d): expected %t, got %t", datum.lo,
+				datum.hi, datum.expected, ok)
+		}
+	}
+	var int8s SortedSet[int8]
+	for i := range 256 {
+		int8s.Add(int8(i - 128))
+	}
+	if !CoversRange(int8s, math.MinInt8, math.MaxInt8) {
+		t.Error("expected full int8 range to be covered")
+	}
+	int8s.Delete(0)
+	if CoversRange(int8s, math.MinInt8, math.MaxInt8) {
+		t.Error("unexpected full int8 range covered")
+	}
+	var empty SortedSet[uint64]
+	if CoversRange(empty, 0, math.MaxUint64) || CoversRange(empty, 1, 1) {
+		t.Error("unexpected range covered by empty set")
+	}
+}
+
+func TestBetween(t *testing.T) {
+	s := New(10, 20, 30, 40, 50, 60, 70, 80, 90)
+	for _, datum := range []struct {
+		lo, hi   int
+		expected string
+	}{
+		{0, 100, "[10 20 30 40 50 60 70 80 90]"},
+		{20, 50, "[20 30 40]"},
+		{25, 55, "[30 40 50]"},
+		{50, 50, "[]"},
+		{60, 20, "[]"},
+		{95, 99, "[]"},
+	} {
+		elements := []int{}
+		for element := range s.Between(datum.lo, datum.hi) {
+			elements = append(elements, element)
+		}
+		if actual := fmt.Sprintf("%v", elements); actual != datum.expected {
+			t.Errorf("Between(%d, %d): expected %s, got %s", datum.lo,
+				datum.hi, datum.expected, actual)
+		}
+	}
+	n := 0
+	for element := range s.Between(20, 80) {
+		if element == 40 {
+			break
+		}
+		n += element
+	}
+	if n != 50 {
+		t.Errorf("expected 50, got %d", n)
+	}
+}
+
+func TestBackward(t *testing.T) {
+	s := New(10, 20, 30, 40, 50, 60, 70, 80, 90)
+	elements := []int{}
+	for element := range s.Backward() {
+		elements = append(elements, element)
+	}
+	check(fmt.Sprintf("%v", elements), len(elements),
+		"[90 80 70 60 50 40 30 20 10]", s.Len(), t)
+	n := 0
+	for element := range s.Backward() {
+		if element == 60 {
+			break
+		}
+		n += element
+	}
+	if n != 240 {
+		t.Errorf("expected 240, got %d", n)
+	}
+}
+
+func TestBackwardX(t *testing.T) {
+	s := New(10, 20, 30)
+	var out strings.Builder
+	for i, v := range s.BackwardX(1) {
+		fmt.Fprintf(&out, "%d:%d ", i, v)
+	}
+	actual := strings.TrimSpace(out.String())
+	if expected := "1:30 2:20 3:10"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	n := 0
+	for i, v := range s.BackwardX() {
+		n += v + i
+	}
+	if n != 63 {
+		t.Errorf("expected 63, got %d", n)
+	}
+	s = FromSlice(rand.New(rand.NewSource(1)).Perm(1000))
+	var ranks []string
+	for rank, v := range s.BackwardX(1) {
+		ranks = append(ranks, fmt.Sprintf("%d:%d", rank, v))
+		if rank == 3 {
+			break
+		}
+	}
+	if actual := strings.Join(ranks, " "); actual != "1:999 2:998 3:997" {
+		t.Errorf("expected \"1:999 2:998 3:997\", got %q", actual)
+	}
+	for range s.BackwardX() {
+		break // must stop cleanly (no panic from yield after break)
+	}
+}
+
+func TestDeleteMinMax(t *testing.T) {
+	var s SortedSet[int]
+	if s.DeleteMin() || s.DeleteMax() {
+		t.Error("unexpectedly deleted from empty set")
+	}
+	for i := range 100 {
+		s.Add(i)
+	}
+	for i := range 30 {
+		if !s.DeleteMin() {
+			t.Error("expected to delete minimum")
+		}
+		if !s.DeleteMax() {
+			t.Error("expected to delete maximum")
+		}
+		if x, _ := s.Min(); x != i+1 {
+			t.Errorf("expected minimum %d, got %d", i+1, x)
+		}
+		if x, _ := s.Max(); x != 98-i {
+			t.Errorf("expected maximum %d, got %d", 98-i, x)
+		}
+		if !s.IsValid() {
+			t.Errorf("invalid tree: %v", s)
+		}
+	}
+	check(fmt.Sprint(s.ToSlice()), s.Len(),
+		fmt.Sprint(slices.Collect(s.Between(30, 70))), 40, t)
+}
+
+func TestFromSlice(t *testing.T) {
+	for size := range 200 {
+		elements := make([]int, 0, size)
+		for i := range size {
+			elements = append(elements, (i*7919)%size, i/2)
+		}
+		s := FromSlice(elements)
+		u := New(elements...)
+		check(s.String(), s.Len(), u.String(), u.Len(), t)
+		if !s.IsValid() {
+			t.Errorf("invalid tree: %v", s)
+		}
+		for i, element := range u.AllX() {
+			if x, ok := s.At(i); !ok || x != element {
+				t.Errorf("At(%d): expected %d true, got %d %t", i, element,
+					x, ok)
+			}
+		}
+	}
+	s := FromSlice([]string{"be", "a", "can", "a"})
+	check(s.String(), s.Len(), `{"a" "be" "can"}`, 3, t)
+}
+
+func BenchmarkFromSlice(b *testing.B) {
+	elements := make([]int, 1000000)
+	for i := range elements {
+		elements[i] = i
+	}
+	for range b.N {
+		FromSlice(elements)
+	}
+}
+
+func TestAddAllDeleteAll(t *testing.T) {
+	s := New(1, 2, 3)
+	if count := s.AddAll(2, 4, 6, 4, 8); count != 3 {
+		t.Errorf("expected 3, got %d", count)
+	}
+	check(s.String(), s.Len(), "{1 2 3 4 6 8}", 6, t)
+	if count := s.AddAll(); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+	if count := s.DeleteAll(1, 5, 6, 6, 9); count != 2 {
+		t.Errorf("expected 2, got %d", count)
+	}
+	check(s.String(), s.Len(), "{2 3 4 8}", 4, t)
+}
+
+func TestDeleteFunc(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+	even := func(x int) bool { return x%2 == 0 }
+	if count := s.DeleteFunc(even); count != 5 {
+		t.Errorf("expected 5, got %d", count)
+	}
+	checkLenAndOrder(&s, 5, t)
+	check(s.String(), s.Len(), "{1 3 5 7 9}", 5, t)
+	if count := s.DeleteFunc(even); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+	s = FromSlice(rand.New(rand.NewSource(1)).Perm(1000))
+	if count := s.DeleteFunc(func(x int) bool { return x%100 == 0 }); count !=
+		10 {
+		t.Errorf("expected 10, got %d", count)
+	}
+	checkLenAndOrder(&s, 990, t)
+	if s.Contains(500) || !s.Contains(501) {
+		t.Error("wrong elements deleted")
+	}
+	s.DeleteFunc(func(int) bool { return true })
+	checkLenAndOrder(&s, 0, t)
+}
+
+func TestAbsorb(t *testing.T) {
+	s := New(1, 2, 3)
+	u := New(10, 11)
+	s.Absorb(&u)
+	checkLenAndOrder(&s, 5, t)
+	check(s.String(), s.Len(), "{1 2 3 10 11}", 5, t)
+	check(u.String(), u.Len(), "{}", 0, t)
+	u = New(5, 10, 20)
+	s.Absorb(&u) // overlapping
+	check(s.String(), s.Len(), "{1 2 3 5 10 11 20}", 7, t)
+	check(u.String(), u.Len(), "{}", 0, t)
+	s.Absorb(&s)
+	s.Absorb(&u)
+	check(s.String(), s.Len(), "{1 2 3 5 10 11 20}", 7, t)
+	u.Absorb(&s)
+	check(u.String(), u.Len(), "{1 2 3 5 10 11 20}", 7, t)
+	check(s.String(), s.Len(), "{}", 0, t)
+	rng := rand.New(rand.NewSource(1))
+	for range 500 {
+		lo, hi := rng.Intn(300), rng.Intn(300)
+		var expected []int
+		var left, right SortedSet[int]
+		for i := range lo {
+			left.Add(i)
+			expected = append(expected, i)
+		}
+		for i := range hi {
+			right.Add(lo + i)
+			expected = append(expected, lo+i)
+		}
+		snapshot := right.Snapshot()
+		if rng.Intn(2) == 0 {
+			left.Absorb(&right)
+		} else {
+			right.Absorb(&left)
+			left, right = right, left
+		}
+		checkLenAndOrder(&left, lo+hi, t)
+		if !slices.Equal(left.ToSlice(), expected) || !right.IsEmpty() {
+			t.Fatalf("Absorb: wrong elements for %d + %d", lo, hi)
+		}
+		left.Add(-1)
+		left.DeleteMin()
+		left.DeleteMax()
+		checkLenAndOrder(&left, max(lo+hi-1, 0), t)
+		for i := range min(lo+hi, 20) {
+			left.Delete(i)
+		}
+		checkLenAndOrder(&snapshot, hi, t) // unaffected snapshot
+		if !slices.Equal(snapshot.ToSlice(), expected[lo:]) {
+			t.Fatalf("Absorb: snapshot changed for %d + %d", lo, hi)
+		}
+	}
+	o := New(10, 11, 12, 13, 14, 15, 16, 17)
+	snapshot := o.Snapshot()
+	var empty SortedSet[int]
+	empty.Absorb(&o)
+	empty.Add(18)
+	empty.Delete(10)
+	check(snapshot.String(), snapshot.Len(), "{10 11 12 13 14 15 16 17}", 8,
+		t)
+	checkLenAndOrder(&snapshot, 8, t)
+	o = FromSlice([]int{10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22,
+		23, 24})
+	snapshot = o.Snapshot()
+	v := New(100, 101)
+	v.Absorb(&o)
+	for i := 10; i < 25; i++ {
+		v.Delete(i)
+	}
+	check(v.String(), v.Len(), "{100 101}", 2, t)
+	checkLenAndOrder(&snapshot, 15, t)
+}
+
+func TestSubtract(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	s.Subtract(New(2, 4, 6, 8, 10, 12))
+	check(s.String(), s.Len(), "{0 1 3 5 7 9}", 6, t)
+	s.Subtract(New[int]())
+	check(s.String(), s.Len(), "{0 1 3 5 7 9}", 6, t)
+	s.Subtract(s)
+	check(s.String(), s.Len(), "{}", 0, t)
+}
+
+func TestRetainAll(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	s.RetainAll(New(2, 4, 6, 8, 10, 12))
+	check(s.String(), s.Len(), "{2 4 6 8}", 4, t)
+	s.RetainAll(s)
+	check(s.String(), s.Len(), "{2 4 6 8}", 4, t)
+	s.RetainAll(New[int]())
+	check(s.String(), s.Len(), "{}", 0, t)
+}
+
+func TestSymmetricDifferenceUpdate(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	u := New(2, 4, 6, 8, 10, 12)
+	d := s.SymmetricDifference(u)
+	s.SymmetricDifferenceUpdate(u)
+	if !d.Equal(s) {
+		t.Errorf("unexpected unequal: d=%v s=%v", d, s)
+	}
+	check(s.String(), s.Len(), "{0 1 3 5 7 9 10 12}", 8, t)
+	s.SymmetricDifferenceUpdate(s)
+	check(s.String(), s.Len(), "{}", 0, t)
+}
+
+func TestIsProperSubsetOfSupersetOf(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	u := s.Clone()
+	if s.IsProperSubsetOf(u) || s.IsProperSupersetOf(u) {
+		t.Error("unexpectedly proper subset or superset of equal set")
+	}
+	x := New(4, 6, 2)
+	if !x.IsProperSubsetOf(s) || x.IsProperSupersetOf(s) {
+		t.Error("unexpectedly not proper subset")
+	}
+	if !s.IsProperSupersetOf(x) || s.IsProperSubsetOf(x) {
+		t.Error("unexpectedly not proper superset")
+	}
+	w := New(10, 11)
+	if w.IsProperSubsetOf(s) || s.IsProperSupersetOf(w) {
+		t.Error("unexpectedly proper subset or superset")
+	}
+	e := New[int]()
+	if !e.IsProperSubsetOf(s) || e.IsProperSubsetOf(e) {
+		t.Error("unexpected empty set result")
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	for _, datum := range []struct {
+		s, u     SortedSet[int]
+		expected float64
+	}{
+		{New[int](), New[int](), 1},
+		{New(1, 2, 3), New[int](), 0},
+		{New(1, 2, 3), New(4, 5), 0},
+		{New(1, 2, 3), New(3, 2, 1), 1},
+		{New(1, 2, 3, 4), New(3, 4, 5, 6), 2.0 / 6.0},
+		{New(0, 2, 4, 6, 8), New(1, 2, 3, 4), 2.0 / 7.0},
+	} {
+		if j := datum.s.Jaccard(datum.u); j != datum.expected {
+			t.Errorf("%v.Jaccard(%v): expected %g, got %g", &datum.s,
+				&datum.u, datum.expected, j)
+		}
+		if j := datum.u.Jaccard(datum.s); j != datum.expected {
+			t.Errorf("%v.Jaccard(%v): expected %g, got %g", &datum.u,
+				&datum.s, datum.expected, j)
+		}
+	}
+}
+
+func TestUnionMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 100 {
+		var s, u SortedSet[int]
+		for range rng.Intn(100) {
+			s.Add(rng.Intn(100))
+		}
+		for range rng.Intn(100) {
+			u.Add(rng.Intn(100))
+		}
+		expected := s.Clone()
+		expected.Unite(u)
+		x := s.Union(u)
+		check(x.String(), x.Len(), expected.String(), expected.Len(), t)
+	}
+}
+
+func makeOverlappingSets() (SortedSet[int], SortedSet[int]) {
+	elements := make([]int, 1000000)
+	for i := range elements {
+		elements[i] = i * 2
+	}
+	s := FromSlice(elements)
+	for i := range elements {
+		elements[i] = i * 3
+	}
+	return s, FromSlice(elements)
+}
+
+func BenchmarkUnion(b *testing.B) {
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		s.Union(u)
+	}
+}
+
+func BenchmarkUnionByUnite(b *testing.B) { // The old Union implementation
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		union := s.Clone()
+		union.Unite(u)
+	}
+}
+
+func TestIntersectionMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 100 {
+		var s, u SortedSet[int]
+		for range rng.Intn(100) {
+			s.Add(rng.Intn(100))
+		}
+		for range rng.Intn(100) {
+			u.Add(rng.Intn(100))
+		}
+		var expected SortedSet[int]
+		for element := range s.All() {
+			if u.Contains(element) {
+				expected.Add(element)
+			}
+		}
+		x := s.Intersection(u)
+		check(x.String(), x.Len(), expected.String(), expected.Len(), t)
+	}
+}
+
+func BenchmarkIntersection(b *testing.B) {
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		s.Intersection(u)
+	}
+}
+
+func BenchmarkIntersectionByContains(b *testing.B) { // The old way
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		var intersection SortedSet[int]
+		for element := range s.All() {
+			if u.Contains(element) {
+				intersection.Add(element)
+			}
+		}
+	}
+}
+
+func TestComplementIn(t *testing.T) {
+	known := New("a", "b", "c", "d", "e")
+	for _, datum := range []struct {
+		enabled SortedSet[string]
+		exp     string
+		size    int
+	}{
+		{New[string](), `{"a" "b" "c" "d" "e"}`, 5},
+		{New("b", "d"), `{"a" "c" "e"}`, 3},
+		{New("a", "b", "c", "d", "e"), "{}", 0},
+		{New("0", "c", "z"), `{"a" "b" "d" "e"}`, 4},
+	} {
+		disabled := datum.enabled.ComplementIn(known)
+		check(disabled.String(), disabled.Len(), datum.exp, datum.size, t)
+	}
+	var none SortedSet[string]
+	one := New("a")
+	complement := one.ComplementIn(none)
+	check(complement.String(), complement.Len(), "{}", 0, t)
+}
+
+func TestDifferenceMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 100 {
+		var s, u SortedSet[int]
+		for range rng.Intn(100) {
+			s.Add(rng.Intn(100))
+		}
+		for range rng.Intn(100) {
+			u.Add(rng.Intn(100))
+		}
+		var expected SortedSet[int]
+		for element := range s.All() {
+			if !u.Contains(element) {
+				expected.Add(element)
+			}
+		}
+		x := s.Difference(u)
+		check(x.String(), x.Len(), expected.String(), expected.Len(), t)
+		if !x.IsValid() {
+			t.Fatalf("invalid tree: %v", x)
+		}
+	}
+}
+
+func BenchmarkDifference(b *testing.B) {
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		s.Difference(u)
+	}
+}
+
+func BenchmarkDifferenceByContains(b *testing.B) { // The old way
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		var diff SortedSet[int]
+		for element := range s.All() {
+			if !u.Contains(element) {
+				diff.Add(element)
+			}
+		}
+	}
+}
+
+func TestAddAscending(t *testing.T) {
+	const size = 1000000
+	var s SortedSet[int]
+	for i := range size {
+		if !s.Add(i) {
+			t.Fatalf("failed to add %d", i)
+		}
+	}
+	if s.Add(size/2) || s.Len() != size || sizeOf(s.root) != size {
+		t.Fatalf("expected %d elements, got %d", size, s.Len())
+	}
+	i := 0
+	for element := range s.All() {
+		if element != i {
+			t.Fatalf("expected %d, got %d", i, element)
+		}
+		i++
+	}
+}
+
+func TestRandomPermutations(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for size := range 150 {
+		var s SortedSet[int]
+		for i, element := range rng.Perm(size) {
+			s.Add(element)
+			checkLenAndOrder(&s, i+1, t)
+		}
+		for i, element := range rng.Perm(size) {
+			if !s.Delete(element) {
+				t.Fatalf("failed to delete %d", element)
+			}
+			if s.Delete(element) {
+				t.Fatalf("deleted %d twice", element)
+			}
+			checkLenAndOrder(&s, size-i-1, t)
+		}
+	}
+}
+
+func checkLenAndOrder(s *SortedSet[int], size int, t *testing.T) {
+	t.Helper()
+	if s.Len() != size || sizeOf(s.root) != size {
+		t.Fatalf("expected %d elements, got %d (tree has %d)", size,
+			s.Len(), sizeOf(s.root))
+	}
+	if !s.IsValid() {
+		t.Fatalf("invalid tree: %v", s)
+	}
+	if !slices.IsSorted(s.ToSlice()) {
+		t.Fatalf("unsorted: %v", s)
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	var s SortedSet[int]
+	if !s.IsValid() {
+		t.Error("unexpectedly invalid empty set")
+	}
+	s = FromSlice([]int{1, 2, 3, 4, 5, 6, 7}) // perfectly balanced & black
+	if !s.IsValid() {
+		t.Error("unexpectedly invalid set")
+	}
+	s.root.red = true // red root
+	if s.IsValid() {
+		t.Error("unexpectedly valid with red root")
+	}
+	s.root.red = false
+	s.root.right.red = true // right-leaning red link
+	if s.IsValid() {
+		t.Error("unexpectedly valid with right-leaning red link")
+	}
+	s.root.right.red = false
+	s.root.left.left.red = true // unequal black height
+	if s.IsValid() {
+		t.Error("unexpectedly valid with unequal black height")
+	}
+	s.root.left.red = true // consecutive red links
+	if s.IsValid() {
+		t.Error("unexpectedly valid with consecutive red links")
+	}
+	s = New(1, 2, 3)
+	s.root.left.element = 5 // out of order
+	if s.IsValid() {
+		t.Error("unexpectedly valid with out of order elements")
+	}
+	s = New(1, 2, 3)
+	s.size = 4
+	if s.IsValid() {
+		t.Error("unexpectedly valid with wrong size")
+	}
+}
+
+func TestToDOT(t *testing.T) {
+	s := New("b", "a", "c", "d")
+	expected := `digraph SortedSet {
+	node [style=filled, fontcolor=white];
+	n0 [label="b", fillcolor=black];
+	n0:sw -> n1;
+	n1 [label="a", fillcolor=black];
+	n0:se -> n2;
+	n2 [label="d", fillcolor=black];
+	n2:sw -> n3;
+	n3 [label="c", fillcolor=red];
+}
+`
+	if actual := s.ToDOT(); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+	var empty SortedSet[int]
+	expected = "digraph SortedSet {\n" +
+		"\tnode [style=filled, fontcolor=white];\n}\n"
+	if actual := empty.ToDOT(); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	quoted := New(`say "hi"`)
+	if actual := quoted.ToDOT(); !strings.Contains(actual,
+		`[label="say \"hi\"", fillcolor=black]`) {
+		t.Errorf("unexpected DOT: %s", actual)
+	}
+}
+
+func TestHeight(t *testing.T) {
+	var s SortedSet[int]
+	if s.Height() != 0 || s.BlackHeight() != 0 {
+		t.Errorf("expected 0 0, got %d %d", s.Height(), s.BlackHeight())
+	}
+	s.Add(1)
+	if s.Height() != 1 || s.BlackHeight() != 1 {
+		t.Errorf("expected 1 1, got %d %d", s.Height(), s.BlackHeight())
+	}
+	s = FromSlice([]int{1, 2, 3, 4, 5, 6, 7})
+	if s.Height() != 3 || s.BlackHeight() != 3 {
+		t.Errorf("expected 3 3, got %d %d", s.Height(), s.BlackHeight())
+	}
+	for i := range 100000 {
+		s.Add(i)
+	}
+	limit := 2 * math.Log2(float64(s.Len()+1))
+	if height := s.Height(); float64(height) > limit ||
+		height < s.BlackHeight() || s.BlackHeight() < 16 {
+		t.Errorf("unexpected height %d and black height %d", height,
+			s.BlackHeight())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	u := s.Filter(func(x int) bool { return x%2 == 0 })
+	check(u.String(), u.Len(), "{0 2 4 6 8}", 5, t)
+	if !u.IsValid() {
+		t.Errorf("invalid tree: %v", u)
+	}
+	u = s.Filter(func(x int) bool { return x > 100 })
+	check(u.String(), u.Len(), "{}", 0, t)
+	w := New("apple", "banana", "avocado", "cherry")
+	x := w.Filter(func(s string) bool { return strings.HasPrefix(s, "a") })
+	check(x.String(), x.Len(), `{"apple" "avocado"}`, 2, t)
+}
+
+func TestReduce(t *testing.T) {
+	s := New("c", "a", "b")
+	text := Reduce(s, "", func(text, x string) string { return text + x })
+	if text != "abc" {
+		t.Errorf("expected \"abc\", got %q", text)
+	}
+	u := New[int]()
+	if total := Reduce(u, 7, func(a, x int) int { return a + x }); total != 7 {
+		t.Errorf("expected 7, got %d", total)
+	}
+}
+
+func TestMap(t *testing.T) {
+	s := New("one", "two", "three", "four", "five", "six")
+	u := Map(s, func(word string) int { return len(word) })
+	check(u.String(), u.Len(), "{3 4 5}", 3, t)
+	if !u.IsValid() {
+		t.Errorf("invalid tree: %v", u)
+	}
+	v := Map(u, func(x int) int { return -x })
+	check(v.String(), v.Len(), "{-5 -4 -3}", 3, t)
+	w := Map(New[int](), func(x int) string { return fmt.Sprint(x) })
+	check(w.String(), w.Len(), "{}", 0, t)
+}
+
+func TestMinByMaxBy(t *testing.T) {
+	words := New("pear", "fig", "banana", "kiwi", "apple", "date")
+	length := func(word string) int { return len(word) }
+	if x, ok := MinBy(words, length); !ok || x != "fig" {
+		t.Errorf("expected \"fig\" true, got %q %t", x, ok)
+	}
+	if x, ok := MaxBy(words, length); !ok || x != "banana" {
+		t.Errorf("expected \"banana\" true, got %q %t", x, ok)
+	}
+	words.Delete("fig")
+	if x, ok := MinBy(words, length); !ok || x != "date" { // first of ties
+		t.Errorf("expected \"date\" true, got %q %t", x, ok)
+	}
+	numbers := New(-7, 3, 5, -2)
+	abs := func(x int) int { return max(x, -x) }
+	if x, ok := MinBy(numbers, abs); !ok || x != -2 {
+		t.Errorf("expected -2 true, got %d %t", x, ok)
+	}
+	if x, ok := MaxBy(numbers, abs); !ok || x != -7 {
+		t.Errorf("expected -7 true, got %d %t", x, ok)
+	}
+	if x, ok := MaxBy(New[int](), abs); ok {
+		t.Errorf("unexpected element in empty set: %d", x)
+	}
+}
+
+func ExampleReduce() {
+	sset := New(1, 2, 3, 4, 5)
+	total := Reduce(sset, 0, func(total, x int) int { return total + x })
+	fmt.Println(total)
+	// Output: 15
+}
+
+func TestSplitAt(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		i           int
+		left, right string
+	}{
+		{-1, "{}", "{10 20 30 40 50}"},
+		{0, "{}", "{10 20 30 40 50}"},
+		{2, "{10 20}", "{30 40 50}"},
+		{4, "{10 20 30 40}", "{50}"},
+		{5, "{10 20 30 40 50}", "{}"},
+		{9, "{10 20 30 40 50}", "{}"},
+	} {
+		left, right := s.SplitAt(datum.i)
+		if left.String() != datum.left || right.String() != datum.right ||
+			!left.IsValid() || !right.IsValid() {
+			t.Errorf("SplitAt(%d): expected %s %s, got %v %v", datum.i,
+				datum.left, datum.right, &left, &right)
+		}
+	}
+	check(s.String(), s.Len(), "{10 20 30 40 50}", 5, t)
+	left, right := s.SplitAt(2)
+	left.Add(25)
+	right.Delete(50)
+	check(s.String(), s.Len(), "{10 20 30 40 50}", 5, t)
+}
+
+func TestSplitByKey(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		x          int
+		less, more string
+		hadX       bool
+	}{
+		{5, "{}", "{10 20 30 40 50}", false},
+		{10, "{}", "{10 20 30 40 50}", true},
+		{25, "{10 20}", "{30 40 50}", false},
+		{30, "{10 20}", "{30 40 50}", true},
+		{50, "{10 20 30 40}", "{50}", true},
+		{55, "{10 20 30 40 50}", "{}", false},
+	} {
+		less, more, hadX := s.SplitByKey(datum.x)
+		if less.String() != datum.less || more.String() != datum.more ||
+			hadX != datum.hadX || !less.IsValid() || !more.IsValid() {
+			t.Errorf("SplitByKey(%d): expected %s %s %t, got %v %v %t",
+				datum.x, datum.less, datum.more, datum.hadX, &less, &more,
+				hadX)
+		}
+	}
+	check(s.String(), s.Len(), "{10 20 30 40 50}", 5, t)
+}
+
+func TestConcat(t *testing.T) {
+	for _, datum := range []struct{ lo, mid, hi int }{
+		{0, 0, 0}, {0, 0, 10}, {0, 10, 10}, {0, 1, 1000}, {0, 999, 1000},
+		{0, 500, 1000}, {0, 3, 200},
+	} {
+		var left, right SortedSet[int]
+		for i := datum.lo; i < datum.mid; i++ {
+			left.Add(i)
+		}
+		for i := datum.mid; i < datum.hi; i++ {
+			right.Add(i)
+		}
+		leftText, rightText := left.String(), right.String()
+		joined, ok := Concat(&left, &right)
+		if !ok {
+			t.Errorf("Concat %d..%d..%d: expected true", datum.lo, datum.mid,
+				datum.hi)
+		}
+		checkLenAndOrder(&joined, datum.hi-datum.lo, t)
+		for i := datum.lo; i < datum.hi; i += 7 {
+			joined.Delete(i)
+		}
+		joined.Add(-1)
+		joined.Add(datum.hi)
+		if !joined.IsValid() {
+			t.Errorf("Concat %d..%d..%d: invalid after changes", datum.lo,
+				datum.mid, datum.hi)
+		}
+		check(left.String(), left.Len(), leftText, datum.mid-datum.lo, t)
+		check(right.String(), right.Len(), rightText, datum.hi-datum.mid, t)
+		joinedText, joinedLen := joined.String(), joined.Len()
+		left.Add(datum.mid - 1) // changing the inputs mustn't affect joined
+		left.Add(-5)
+		right.Add(datum.hi + 5)
+		for i := datum.lo; i < datum.hi; i += 3 {
+			left.Delete(i)
+			right.Delete(i)
+		}
+		checkLenAndOrder(&joined, joinedLen, t)
+		check(joined.String(), joined.Len(), joinedText, joinedLen, t)
+		checkLenAndOrder(&left, left.Len(), t)
+		checkLenAndOrder(&right, right.Len(), t)
+	}
+	left, right := New(1, 2, 3), New(5, 6)
+	joined, _ := Concat(&left, &right)
+	left.Add(4)
+	checkLenAndOrder(&joined, 5, t)
+	check(joined.String(), joined.Len(), "{1 2 3 5 6}", 5, t)
+	left, right = New(1, 2, 5), New(5, 6)
+	if joined, ok := Concat(&left, &right); ok || !joined.IsEmpty() {
+		t.Errorf("expected empty false, got %v %t", &joined, ok)
+	}
+	if joined, ok := Concat(&right, &left); ok || !joined.IsEmpty() {
+		t.Errorf("expected empty false, got %v %t", &joined, ok)
+	}
+}
+
+func TestAnyMatchAllMatch(t *testing.T) {
+	s := New(2, 4, 6, 8, 9)
+	calls := 0
+	isEven := func(x int) bool {
+		calls++
+		return x%2 == 0
+	}
+	if !s.AnyMatch(isEven) || calls != 1 {
+		t.Errorf("expected true after 1 call, got %d calls", calls)
+	}
+	calls = 0
+	if s.AllMatch(isEven) || calls != 5 {
+		t.Errorf("expected false after 5 calls, got %d calls", calls)
+	}
+	s.Delete(9)
+	if !s.AllMatch(isEven) {
+		t.Error("expected all even")
+	}
+	if s.AnyMatch(func(x int) bool { return x > 10 }) {
+		t.Error("unexpected match")
+	}
+	var u SortedSet[int]
+	if u.AnyMatch(isEven) || !u.AllMatch(isEven) {
+		t.Error("unexpected empty set result")
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := New("a", "be", "can", "dent", "ebony", "for")
+	if count := s.Count(func(s string) bool { return len(s) > 3 }); count != 2 {
+		t.Errorf("expected 2, got %d", count)
+	}
+	var u SortedSet[int]
+	if count := u.Count(func(int) bool { return true }); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+}
+
+func TestHeadTailSubSet(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		x        SortedSet[int]
+		expected string
+	}{
+		{s.HeadSet(30), "{10 20}"},
+		{s.HeadSet(35), "{10 20 30}"},
+		{s.HeadSet(10), "{}"},
+		{s.HeadSet(99), "{10 20 30 40 50}"},
+		{s.TailSet(30), "{30 40 50}"},
+		{s.TailSet(35), "{40 50}"},
+		{s.TailSet(0), "{10 20 30 40 50}"},
+		{s.TailSet(51), "{}"},
+		{s.SubSet(20, 40), "{20 30}"},
+		{s.SubSet(15, 45), "{20 30 40}"},
+		{s.SubSet(40, 20), "{}"},
+		{s.SubSet(30, 30), "{}"},
+	} {
+		if actual := datum.x.String(); actual != datum.expected {
+			t.Errorf("expected %s, got %s", datum.expected, actual)
+		}
+		if !datum.x.IsValid() {
+			t.Errorf("invalid tree: %v", &datum.x)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	m := map[string]int{"one": 1, "two": 2, "three": 3}
+	s := Collect(maps.Keys(m))
+	check(s.String(), s.Len(), `{"one" "three" "two"}`, 3, t)
+	u := Collect(maps.Values(map[string]int{"a": 1, "b": 2, "c": 1}))
+	check(u.String(), u.Len(), "{1 2}", 2, t)
+	w := New(10, 20, 30, 40, 50)
+	x := Collect(w.Between(20, 50))
+	check(x.String(), x.Len(), "{20 30 40}", 3, t)
+	if !x.IsValid() {
+		t.Errorf("invalid tree: %v", x)
+	}
+	var e SortedSet[int]
+	x = Collect(e.All())
+	check(x.String(), x.Len(), "{}", 0, t)
+}
+
+func TestUnionAll(t *testing.T) {
+	x := UnionAll[int]()
+	check(x.String(), x.Len(), "{}", 0, t)
+	x = UnionAll(New(3, 1, 2))
+	check(x.String(), x.Len(), "{1 2 3}", 3, t)
+	x = UnionAll(New(1, 5), New(2, 4, 6), New[int](), New(1, 3, 9),
+		New(7, 8))
+	check(x.String(), x.Len(), "{1 2 3 4 5 6 7 8 9}", 9, t)
+	if !x.IsValid() {
+		t.Errorf("invalid tree: %v", x)
+	}
+}
+
+func TestIntersectionAll(t *testing.T) {
+	x := IntersectionAll[int]()
+	check(x.String(), x.Len(), "{}", 0, t)
+	x = IntersectionAll(New(3, 1, 2))
+	check(x.String(), x.Len(), "{1 2 3}", 3, t)
+	x = IntersectionAll(New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9), New(2, 4, 6, 8),
+		New(1, 2, 3, 4, 5, 6))
+	check(x.String(), x.Len(), "{2 4 6}", 3, t)
+	x = IntersectionAll(New(1, 2), New(3, 4), New(1, 2))
+	check(x.String(), x.Len(), "{}", 0, t)
+}
+
+func TestCommonAll(t *testing.T) {
+	a := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	b := New(2, 4, 6, 8, 10)
+	c := New(1, 2, 3, 4, 5, 6)
+	for _, datum := range []struct {
+		ssets    []SortedSet[int]
+		expected []int
+	}{
+		{nil, nil},
+		{[]SortedSet[int]{New(3, 1, 2)}, []int{1, 2, 3}},
+		{[]SortedSet[int]{a, b, c}, []int{2, 4, 6}},
+		{[]SortedSet[int]{c, a, b}, []int{2, 4, 6}},
+		{[]SortedSet[int]{a, New[int](), b}, nil},
+		{[]SortedSet[int]{New(1, 2), New(3, 4), New(1, 2)}, nil},
+	} {
+		actual := slices.Collect(CommonAll(datum.ssets...))
+		if !slices.Equal(actual, datum.expected) {
+			t.Errorf("expected %v, got %v", datum.expected, actual)
+		}
+		x := IntersectionAll(datum.ssets...)
+		if !slices.Equal(actual, x.ToSlice()) {
+			t.Errorf("expected %v, got %v", &x, actual)
+		}
+	}
+	var first []int
+	for element := range CommonAll(a, b, c) {
+		first = append(first, element)
+		if len(first) == 2 {
+			break
+		}
+	}
+	if !slices.Equal(first, []int{2, 4}) {
+		t.Errorf("expected [2 4], got %v", first)
+	}
+}
+
+func TestTake(t *testing.T) {
+	s := New(1, 2, 3)
+	if x, ok := s.Take(2); !ok || x != 2 {
+		t.Errorf("expected 2 true, got %d %t", x, ok)
+	}
+	if x, ok := s.Take(2); ok || x != 0 {
+		t.Errorf("expected 0 false, got %d %t", x, ok)
+	}
+	check(s.String(), s.Len(), "{1 3}", 2, t)
+}
+
+func TestChunks(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, 6, 7)
+	for _, datum := range []struct {
+		size     int
+		expected string
+	}{
+		{1, "[[1] [2] [3] [4] [5] [6] [7]]"},
+		{3, "[[1 2 3] [4 5 6] [7]]"},
+		{7, "[[1 2 3 4 5 6 7]]"},
+		{10, "[[1 2 3 4 5 6 7]]"},
+	} {
+		chunks := slices.Collect(s.Chunks(datum.size))
+		if actual := fmt.Sprint(chunks); actual != datum.expected {
+			t.Errorf("Chunks(%d): expected %s, got %s", datum.size,
+				datum.expected, actual)
+		}
+	}
+	n := 0
+	for chunk := range s.Chunks(2) {
+		if n++; len(chunk) != 2 || n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected 2, got %d", n)
+	}
+	var u SortedSet[int]
+	for chunk := range u.Chunks(2) {
+		t.Errorf("unexpected chunk %v", chunk)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	s.Chunks(0)
+}
+
+func TestAllFrom(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		start    int
+		expected string
+	}{
+		{0, "[10 20 30 40 50]"},
+		{30, "[30 40 50]"},
+		{35, "[40 50]"},
+		{50, "[50]"},
+		{51, "[]"},
+	} {
+		elements := []int{}
+		for element := range s.AllFrom(datum.start) {
+			elements = append(elements, element)
+		}
+		if actual := fmt.Sprint(elements); actual != datum.expected {
+			t.Errorf("AllFrom(%d): expected %s, got %s", datum.start,
+				datum.expected, actual)
+		}
+	}
+	n := 0
+	for element := range s.AllFrom(20) {
+		if element == 40 {
+			break
+		}
+		n += element
+	}
+	if n != 50 {
+		t.Errorf("expected 50, got %d", n)
+	}
+}
+
+func TestUntil(t *testing.T) {
+	s := New(10, 20, 30, 40, 50)
+	for _, datum := range []struct {
+		hi       int
+		expected string
+	}{
+		{0, "[]"},
+		{10, "[]"},
+		{30, "[10 20]"},
+		{35, "[10 20 30]"},
+		{51, "[10 20 30 40 50]"},
+	} {
+		elements := []int{}
+		for element := range s.Until(datum.hi) {
+			elements = append(elements, element)
+		}
+		if actual := fmt.Sprint(elements); actual != datum.expected {
+			t.Errorf("Until(%d): expected %s, got %s", datum.hi,
+				datum.expected, actual)
+		}
+	}
+	n := 0
+	for element := range s.Until(40) {
+		if element == 30 {
+			break
+		}
+		n += element
+	}
+	if n != 30 {
+		t.Errorf("expected 30, got %d", n)
+	}
+}
+
+func TestGoString(t *testing.T) {
+	type ID uint8
+	for _, datum := range []struct {
+		actual, expected string
+	}{
+		{fmt.Sprintf("%#v", New(3, 1, 2)), "sortedset.New(1, 2, 3)"},
+		{fmt.Sprintf("%#v", New[int]()), "sortedset.New[int]()"},
+		{fmt.Sprintf("%#v", New("b", "a")), `sortedset.New("a", "b")`},
+		{fmt.Sprintf("%#v", New[string]()), "sortedset.New[string]()"},
+		{fmt.Sprintf("%#v", New[int64](7)), "sortedset.New[int64](7)"},
+		{fmt.Sprintf("%#v", New[ID](2, 1)),
+			"sortedset.New[sortedset.ID](0x1, 0x2)"},
+	} {
+		if datum.actual != datum.expected {
+			t.Errorf("expected %s, got %s", datum.expected, datum.actual)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	s := New(10, 255, 3)
+	words := New("b", "a")
+	for _, datum := range []struct {
+		actual, expected string
+	}{
+		{fmt.Sprintf("%v", s), "{3 10 255}"},
+		{fmt.Sprintf("%s", s), "{3 10 255}"},
+		{fmt.Sprint(s), s.String()},
+		{fmt.Sprintf("%x", s), "{3 a ff}"},
+		{fmt.Sprintf("%#x", s), "{0x3 0xa 0xff}"},
+		{fmt.Sprintf("%4d", s), "{   3   10  255}"},
+		{fmt.Sprintf("%-3d|", s), "{3   10  255}|"},
+		{fmt.Sprintf("%#v", s), "sortedset.New(3, 10, 255)"},
+		{fmt.Sprintf("%v", words), `{"a" "b"}`},
+		{fmt.Sprintf("%s", words), words.String()},
+		{fmt.Sprintf("%3.1q", words), `{"a" "b"}`},
+		{fmt.Sprintf("%x", words), "{61 62}"},
+		{fmt.Sprintf("%d", New[int]()), "{}"},
+	} {
+		if datum.actual != datum.expected {
+			t.Errorf("expected %q, got %q", datum.expected, datum.actual)
+		}
+	}
+}
+
+func TestIsSingleton(t *testing.T) {
+	var s SortedSet[string]
+	if s.IsSingleton() {
+		t.Error("unexpected singleton for empty set")
+	}
+	s.Add("one")
+	if !s.IsSingleton() {
+		t.Error("expected singleton")
+	}
+	s.Add("two")
+	if s.IsSingleton() {
+		t.Error("unexpected singleton for two elements")
+	}
+	if !isString[string]() || isString[int]() {
+		t.Error("unexpected isString result")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	s := New(3, 1, 2)
+	if actual := s.Join(","); actual != "1,2,3" {
+		t.Errorf("expected \"1,2,3\", got %q", actual)
+	}
+	u := New("b", "a c")
+	if actual := u.Join(" "); actual != `"a c" "b"` {
+		t.Errorf(`expected "\"a c\" \"b\"", got %q`, actual)
+	}
+	var w SortedSet[int]
+	if actual := w.Join(", "); actual != "" {
+		t.Errorf("expected \"\", got %q", actual)
+	}
+}
+
+func TestEqualLockstep(t *testing.T) {
+	s := New(1, 2, 3, 4)
+	for _, datum := range []struct {
+		u     SortedSet[int]
+		equal bool
+	}{
+		{New(4, 3, 2, 1), true},
+		{New(1, 2, 3, 5), false},
+		{New(0, 2, 3, 4), false},
+		{New(1, 2, 3), false},
+		{s, true},
+	} {
+		if s.Equal(datum.u) != datum.equal {
+			t.Errorf("%v.Equal(%v): expected %t", &s, &datum.u, datum.equal)
+		}
+	}
+	var e, f SortedSet[string]
+	if !e.Equal(f) {
+		t.Error("expected empty sets to be equal")
+	}
+}
+
+func makeEqualSets() (SortedSet[int], SortedSet[int]) {
+	var s, u SortedSet[int]
+	for i := range 1000000 {
+		s.Add(i)
+		u.Add(999999 - i)
+	}
+	return s, u
+}
+
+func BenchmarkEqual(b *testing.B) {
+	s, u := makeEqualSets()
+	b.ResetTimer()
+	for range b.N {
+		if !s.Equal(u) {
+			b.Fatal("unexpectedly unequal")
+		}
+	}
+}
+
+func BenchmarkEqualByContains(b *testing.B) { // The old Equal implementation
+	s, u := makeEqualSets()
+	b.ResetTimer()
+	for range b.N {
+		for element := range s.All() {
+			if !u.Contains(element) {
+				b.Fatal("unexpectedly unequal")
+			}
+		}
+	}
+}
+
+func TestEqualElements(t *testing.T) {
+	s := New(1, 2, 3)
+	for _, datum := range []struct {
+		elements []int
+		expected bool
+	}{
+		{[]int{1, 2, 3}, true},
+		{[]int{1, 2}, false},
+		{[]int{1, 2, 3, 4}, false},
+		{[]int{1, 3, 2}, false},
+		{[]int{}, false},
+	} {
+		if ok := s.EqualElements(slices.Values(datum.elements)); ok !=
+			datum.expected {
+			t.Errorf("EqualElements(%v): expected %t, got %t",
+				datum.elements, datum.expected, ok)
+		}
+	}
+	u := NewReversed(3, 2, 1)
+	if !s.EqualElements(u.Backward()) || s.EqualElements(u.All()) {
+		t.Error("unexpected EqualElements result")
+	}
+	var empty SortedSet[int]
+	if !empty.EqualElements(slices.Values([]int{})) {
+		t.Error("expected empty set to equal empty sequence")
+	}
+}
+
+func TestOverlapCount(t *testing.T) {
+	for _, datum := range []struct {
+		s, u     SortedSet[int]
+		expected int
+	}{
+		{New[int](), New[int](), 0},
+		{New(1, 2, 3), New[int](), 0},
+		{New(1, 2, 3), New(4, 5), 0},
+		{New(1, 2, 3), New(3, 2, 1), 3},
+		{New(0, 2, 4, 6, 8), New(1, 2, 3, 4, 9), 2},
+	} {
+		if count := datum.s.OverlapCount(datum.u); count != datum.expected {
+			t.Errorf("%v.OverlapCount(%v): expected %d, got %d", &datum.s,
+				&datum.u, datum.expected, count)
+		}
+		if count := datum.u.OverlapCount(datum.s); count != datum.expected {
+			t.Errorf("%v.OverlapCount(%v): expected %d, got %d", &datum.u,
+				&datum.s, datum.expected, count)
+		}
+	}
+}
+
+func TestDifferenceCount(t *testing.T) {
+	for _, datum := range []struct {
+		s, u     SortedSet[int]
+		expected int
+	}{
+		{New[int](), New[int](), 0},
+		{New[int](), New(1, 2), 0},
+		{New(1, 2, 3), New[int](), 3},
+		{New(1, 2, 3), New(4, 5), 3},
+		{New(4, 5), New(1, 2, 3), 2},
+		{New(1, 2, 3), New(3, 2, 1), 0},
+		{New(0, 2, 4, 6, 8), New(1, 2, 3, 4, 9), 3},
+		{New(1, 2, 3, 4, 9), New(0, 2, 4, 6, 8), 3},
+	} {
+		if count := datum.s.DifferenceCount(datum.u); count !=
+			datum.expected {
+			t.Errorf("%v.DifferenceCount(%v): expected %d, got %d",
+				&datum.s, &datum.u, datum.expected, count)
+		}
+		difference := datum.s.Difference(datum.u)
+		if count := datum.s.DifferenceCount(datum.u); count !=
+			difference.Len() {
+			t.Errorf("%v.DifferenceCount(%v): expected %d, got %d",
+				&datum.s, &datum.u, difference.Len(), count)
+		}
+	}
+	s := New(1, 2, 3)
+	if count := s.DifferenceCount(s); count != 0 {
+		t.Errorf("expected 0, got %d", count)
+	}
+}
+
+func TestSharesAtLeast(t *testing.T) {
+	s := New(0, 2, 4, 6, 8)
+	u := New(1, 2, 3, 4, 9)
+	for _, datum := range []struct {
+		n        int
+		expected bool
+	}{{-1, true}, {0, true}, {1, true}, {2, true}, {3, false}, {6, false}} {
+		if ok := s.SharesAtLeast(u, datum.n); ok != datum.expected {
+			t.Errorf("SharesAtLeast(%d): expected %t, got %t", datum.n,
+				datum.expected, ok)
+		}
+		if ok := u.SharesAtLeast(s, datum.n); ok != datum.expected {
+			t.Errorf("SharesAtLeast(%d): expected %t, got %t", datum.n,
+				datum.expected, ok)
+		}
+	}
+	var empty SortedSet[int]
+	if !empty.SharesAtLeast(s, 0) || empty.SharesAtLeast(s, 1) {
+		t.Error("unexpected SharesAtLeast result for empty set")
+	}
+}
+
+func BenchmarkSharesAtLeast(b *testing.B) {
+	s, u := makeOverlappingSets()
+	b.ResetTimer()
+	for range b.N {
+		s.SharesAtLeast(u, 1000)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	s := New(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	evens, odds := s.Partition(func(x int) bool { return x%2 == 0 })
+	check(evens.String(), evens.Len(), "{0 2 4 6 8}", 5, t)
+	check(odds.String(), odds.Len(), "{1 3 5 7 9}", 5, t)
+	if !evens.IsValid() || !odds.IsValid() {
+		t.Error("invalid tree")
+	}
+	yes, no := s.Partition(func(x int) bool { return x < 100 })
+	check(yes.String(), yes.Len(), s.String(), s.Len(), t)
+	check(no.String(), no.Len(), "{}", 0, t)
+}
+
+func TestMemStats(t *testing.T) {
+	var s SortedSet[int64]
+	nodes, empty := s.MemStats()
+	if nodes != 0 || empty <= 0 {
+		t.Errorf("expected 0 nodes and some bytes, got %d %d", nodes, empty)
+	}
+	for i := range 100 {
+		s.Add(int64(i))
+	}
+	nodes, full := s.MemStats()
+	nodeSize := full - empty
+	if nodes != 100 || nodeSize%100 != 0 || nodeSize/100 < 8+8+3*8 {
+		t.Errorf("expected 100 nodes of at least 40 bytes, got %d %d", nodes,
+			nodeSize)
+	}
+	s.ClearRetaining()
+	if nodes, cleared := s.MemStats(); nodes != 0 || cleared != full {
+		t.Errorf("expected 0 %d, got %d %d", full, nodes, cleared)
+	}
+	u := NewWithCapacity[int64](100)
+	if nodes, preallocated := u.MemStats(); nodes != 0 ||
+		preallocated != full {
+		t.Errorf("expected 0 %d, got %d %d", full, nodes, preallocated)
+	}
+}
+
+func TestNewWithCapacity(t *testing.T) {
+	s := NewWithCapacity[int](100)
+	if !s.IsEmpty() {
+		t.Errorf("expected empty set, got %v", s)
+	}
+	for i := range 150 { // exceed the capacity
+		s.Add(149 - i)
+	}
+	s.DeleteAll(1, 3, 5)
+	if s.Len() != 147 || !s.IsValid() {
+		t.Errorf("expected 147 elements, got %d", s.Len())
+	}
+	if x, _ := s.At(1); x != 2 {
+		t.Errorf("expected 2, got %d", x)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		u := NewWithCapacity[int](1000)
+		for i := range 1000 {
+			u.Add(i)
+		}
+	})
+	if allocs > 2 { // the allocator and its slab
+		t.Errorf("expected 2 allocations, got %g", allocs)
+	}
+	type holder struct{ sset SortedSet[int] }
+	fill := func(h holder, lo, hi int) holder { // moves the SortedSet
+		for i := lo; i < hi; i++ {
+			h.sset.Add(i)
+		}
+		return h
+	}
+	allocs = testing.AllocsPerRun(10, func() {
+		h := holder{NewWithCapacity[int](1000)}
+		for i := range 10 {
+			h = fill(h, i*100, (i+1)*100)
+		}
+	})
+	if allocs > 2 {
+		t.Errorf("expected 2 allocations after moves, got %g", allocs)
+	}
+	a := NewWithCapacity[int](10)
+	b := a // copied before use
+	a.Add(1)
+	a.Add(2)
+	b.Add(7)
+	b.Add(8)
+	c := a // copied after use
+	c.Clear()
+	c.Add(3)
+	a.Add(4)
+	checkLenAndOrder(&a, 3, t)
+	checkLenAndOrder(&b, 2, t)
+	checkLenAndOrder(&c, 1, t)
+	check(a.String(), a.Len(), "{1 2 4}", 3, t)
+	check(b.String(), b.Len(), "{7 8}", 2, t)
+	check(c.String(), c.Len(), "{3}", 1, t)
+}
+
+func TestSnapshot(t *testing.T) {
+	s := New(1, 2, 3, 4, 5, 6, 7, 8, 9)
+	u := s.Snapshot()
+	s.Add(10)
+	s.Delete(1)
+	u.Delete(9)
+	u.Add(0)
+	check(s.String(), s.Len(), "{2 3 4 5 6 7 8 9 10}", 9, t)
+	check(u.String(), u.Len(), "{0 1 2 3 4 5 6 7 8}", 9, t)
+	rng := rand.New(rand.NewSource(1))
+	versions := []SortedSet[int]{FromSlice([]int{1, 3, 5, 7, 9})}
+	expected := []map[int]bool{{1: true, 3: true, 5: true, 7: true, 9: true}}
+	for range 5000 {
+		i := rng.Intn(len(versions))
+		if rng.Intn(20) == 0 {
+			versions = append(versions, versions[i].Snapshot())
+			expected = append(expected, expected[i])
+			expected[i] = maps.Clone(expected[i])
+			continue
+		}
+		x := rng.Intn(100)
+		if rng.Intn(2) == 0 {
+			versions[i].Add(x)
+			expected[i][x] = true
+		} else {
+			versions[i].Delete(x)
+			delete(expected[i], x)
+		}
+	}
+	for i := range versions {
+		checkLenAndOrder(&versions[i], len(expected[i]), t)
+		for element := range versions[i].All() {
+			if !expected[i][element] {
+				t.Fatalf("version %d: unexpected element %d", i, element)
+			}
+		}
+	}
+}
+
+func TestConcurrentModification(t *testing.T) {
+	const message = "sortedset: concurrent modification during iteration"
+	s := New(1, 2, 3, 4, 5)
+	expectPanic := func(name string, body func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("%s: expected panic", name)
+			} else if r != message {
+				t.Errorf("%s: unexpected panic: %v", name, r)
+			}
+		}()
+		body()
+	}
+	expectPanic("Add", func() {
+		for element := range s.All() {
+			s.Add(element + 10)
+		}
+	})
+	expectPanic("Delete", func() {
+		for element := range s.Backward() {
+			s.Delete(element)
+		}
+	})
+	expectPanic("Clear", func() {
+		for range s.AllX() {
+			s.Clear()
+		}
+	})
+	s = New(1, 2, 3, 4, 5)
+	expectPanic("Between", func() {
+		for element := range s.Between(2, 5) {
+			s.Add(element + 10)
+		}
+	})
+	expectPanic("AllFrom", func() {
+		for element := range s.AllFrom(3) {
+			s.Delete(element)
+		}
+	})
+	expectPanic("Until", func() {
+		for element := range s.Until(4) {
+			s.Add(-element)
+		}
+	})
+	s = New(1, 2, 3, 4, 5)
+	for element := range s.All() {
+		s.Add(element) // no change so no panic
+	}
+	for element := range s.All() {
+		if element == 3 {
+			s.Delete(element)
+			break // no panic since we stop iterating
+		}
+	}
+	check(s.String(), s.Len(), "{1 2 4 5}", 4, t)
+}
+
+func TestClearRetaining(t *testing.T) {
+	s := New(5, 3, 1, 4, 2)
+	u := s.Snapshot()
+	s.Add(6)
+	s.ClearRetaining()
+	check(s.String(), s.Len(), "{}", 0, t)
+	check(u.String(), u.Len(), "{1 2 3 4 5}", 5, t) // shared nodes kept
+	for range 3 {
+		for i := range 100 {
+			s.Add(99 - i)
+		}
+		checkLenAndOrder(&s, 100, t)
+		s.ClearRetaining()
+	}
+	check(u.String(), u.Len(), "{1 2 3 4 5}", 5, t)
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := range 100 {
+			s.Add(i)
+		}
+		s.ClearRetaining()
+	})
+	if allocs > 0 {
+		t.Errorf("expected no allocations, got %g", allocs)
+	}
+	c := New(1, 2, 3, 4, 5)
+	c.ClearRetaining()
+	d := c // copied after recycling
+	for i := range 5 {
+		c.Add(i)
+		d.Add(10 + i)
+	}
+	checkLenAndOrder(&c, 5, t)
+	checkLenAndOrder(&d, 5, t)
+	check(c.String(), c.Len(), "{0 1 2 3 4}", 5, t)
+	check(d.String(), d.Len(), "{10 11 12 13 14}", 5, t)
+}
+
+func TestReplaceKeepsSpareNodes(t *testing.T) {
+	spare := func(s *SortedSet[int]) int {
+		if s.alloc == nil || !s.owner {
+			return -1
+		}
+		count := len(s.alloc.slab)
+		for root := s.alloc.free; root != nil; root = root.left {
+			count++
+		}
+		return count
+	}
+	s := NewWithCapacity[int](200)
+	for i := range 100 {
+		s.Add(i)
+	}
+	if count := spare(&s); count != 100 {
+		t.Fatalf("expected 100 spare nodes, got %d", count)
+	}
+	s.RemoveRange(0, 90) // rebuilds, recycling the old tree's nodes
+	checkLenAndOrder(&s, 10, t)
+	if count := spare(&s); count != 200 {
+		t.Errorf("expected 200 spare nodes, got %d", count)
+	}
+	s.KeepLargest(5)
+	checkLenAndOrder(&s, 5, t)
+	if err := s.UnmarshalJSON([]byte("[1,2,3]")); err != nil {
+		t.Fatal(err)
+	}
+	check(s.String(), s.Len(), "{1 2 3}", 3, t)
+	if count := spare(&s); count < 200 { // rebuilt trees' nodes are added
+		t.Errorf("expected at least 200 spare nodes, got %d", count)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := range 150 {
+			s.Add(i)
+		}
+		s.ClearRetaining()
+	})
+	if allocs > 0 {
+		t.Errorf("expected no allocations, got %g", allocs)
+	}
+}
+
+func BenchmarkFillClear(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var s SortedSet[int]
+		for i := range 10000 {
+			for j := range 100 {
+				s.Add((i + j*7) % 100)
+			}
+			s.Clear()
+		}
+	}
+}
+
+func BenchmarkFillClearRetaining(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var s SortedSet[int]
+		for i := range 10000 {
+			for j := range 100 {
+				s.Add((i + j*7) % 100)
+			}
+			s.ClearRetaining()
+		}
+	}
+}
+
+func BenchmarkAdd100k(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var s SortedSet[int]
+		for i := range 100000 {
+			s.Add(i)
+		}
+	}
+}
+
+func BenchmarkAdd100kWithCapacity(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		s := NewWithCapacity[int](100000)
+		for i := range 100000 {
+			s.Add(i)
+		}
+	}
+}
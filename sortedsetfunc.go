@@ -0,0 +1,375 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// SortedSetFunc is a sorted set whose elements are ordered by a
+// user-supplied less function rather than by the < operator, so it can
+// hold elements of any type (e.g., structs), or use a custom ordering
+// (e.g., case-insensitive strings). Two elements a and b are considered
+// equal if neither less(a, b) nor less(b, a) is true.
+//
+// The less function must be a strict weak ordering. For floating-point
+// elements this means using, say, [cmp.Less] rather than <, since with <
+// a NaN would be considered equal to every element.
+//
+// The elements yielded by [SortedSetFunc.All] and the other accessors are
+// copies, so changing a yielded struct's fields can't affect the
+// SortedSetFunc. But if E is a pointer type (or contains pointers that the
+// less function follows), changing an element in a way that affects its
+// ordering will silently break the SortedSetFunc. To catch such mistakes
+// during development, build with -tags sortedset_debug: then All and
+// Backward panic if they find the elements out of order.
+//
+// SortedSetFunc's zero value is not usable since it has no less function;
+// create with [NewFunc]:
+//
+//	set := NewFunc(func(a, b string) bool {
+//		return strings.ToLower(a) < strings.ToLower(b)
+//	}, "one", "Two", "THREE")
+type SortedSetFunc[E any] struct {
+	root *node[E]
+	size int
+	less func(a, b E) bool
+}
+
+// NewFunc returns a new SortedSetFunc that orders its elements using the
+// given less function and that contains the given elements (if any).
+// NewFunc panics if less is nil.
+func NewFunc[E any](less func(a, b E) bool, elements ...E) SortedSetFunc[E] {
+	if less == nil {
+		panic("sortedset: NewFunc requires a non-nil less function")
+	}
+	sset := SortedSetFunc[E]{less: less}
+	for _, element := range elements {
+		sset.Add(element)
+	}
+	return sset
+}
+
+// NewReversed returns a new SortedSetFunc that contains the given elements
+// (if any) in descending order. So [SortedSetFunc.All] and
+// [SortedSetFunc.String] go from largest to smallest, and since Min and
+// Max follow the set's own order, [SortedSetFunc.Min] returns the largest
+// element and [SortedSetFunc.Max] returns the smallest. For example:
+//
+//	top := NewReversed(scores...)
+//	best, ok := top.Min() // the highest score
+func NewReversed[E Comparable](elements ...E) SortedSetFunc[E] {
+	return NewFunc(func(a, b E) bool { return b < a }, elements...)
+}
+
+// Add adds a new element into the SortedSetFunc and returns true; or does
+// nothing and returns false if an equal element is already present.
+func (me *SortedSetFunc[E]) Add(element E) bool {
+	inserted := false
+	me.root, inserted = me.insert(me.root, element)
+	me.root.red = false
+	if inserted {
+		me.size++
+	}
+	return inserted
+}
+
+// Replace adds x into the SortedSetFunc and returns the zero value and
+// false if no equal element was already present; otherwise replaces the
+// stored element that is equal to x with x and returns the old stored
+// element and true. This makes it possible to update the parts of an
+// element that the less function ignores (e.g., a struct's payload fields)
+// while keeping its key. For example:
+//
+//	old, existed := records.Replace(record{id: 7, name: "updated"})
+func (me *SortedSetFunc[E]) Replace(x E) (E, bool) {
+	root := me.root
+	for root != nil {
+		if me.less(x, root.element) {
+			root = root.left
+		} else if me.less(root.element, x) {
+			root = root.right
+		} else {
+			old := root.element
+			root.element = x
+			return old, true
+		}
+	}
+	me.Add(x)
+	var zero E
+	return zero, false
+}
+
+func (me *SortedSetFunc[E]) insert(root *node[E], element E) (*node[E],
+	bool,
+) {
+	inserted := false
+	if root == nil { // If element was in the SortedSetFunc it would go here
+		return &node[E]{element: element, red: true, size: 1}, true
+	}
+	if me.less(element, root.element) {
+		root.left, inserted = me.insert(root.left, element)
+	} else if me.less(root.element, element) {
+		root.right, inserted = me.insert(root.right, element)
+	}
+	resize(root)
+	root = insertRotation(nil, root)
+	return root, inserted
+}
+
+// Len returns the number of items in the SortedSetFunc.
+func (me *SortedSetFunc[E]) Len() int { return me.size }
+
+// IsEmpty returns true if there are no elements in the SortedSetFunc;
+// otherwise returns false.
+func (me *SortedSetFunc[E]) IsEmpty() bool { return me.size == 0 }
+
+// All returns a for .. range iterable of the SortedSetFunc's elements,
+// e.g., for element := range sset.All()
+func (me *SortedSetFunc[E]) All() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		all(me.root, yield)
+		if debug {
+			me.checkOrder()
+		}
+	}
+}
+
+// Backward returns a for .. range iterable of the SortedSetFunc's
+// elements in descending order, e.g.,
+// for element := range sset.Backward()
+func (me *SortedSetFunc[E]) Backward() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		backward(me.root, yield)
+		if debug {
+			me.checkOrder()
+		}
+	}
+}
+
+// checkOrder panics if the SortedSetFunc's elements aren't in strictly
+// ascending order, which can only happen if an element has been changed in
+// place, e.g., through a pointer.
+func (me *SortedSetFunc[E]) checkOrder() {
+	if !me.isOrdered() {
+		panic("sortedset: SortedSetFunc elements out of order: was an " +
+			"element changed in place?")
+	}
+}
+
+func (me *SortedSetFunc[E]) isOrdered() bool {
+	walker := newWalker(me.root)
+	previous, ok := walker.next()
+	for ok {
+		var element E
+		if element, ok = walker.next(); ok {
+			if !me.less(previous, element) {
+				return false
+			}
+			previous = element
+		}
+	}
+	return true
+}
+
+// Contains returns true if an element equal to the given element is in the
+// SortedSetFunc; otherwise false.
+func (me *SortedSetFunc[E]) Contains(element E) bool {
+	root := me.root
+	for root != nil {
+		if me.less(element, root.element) {
+			root = root.left
+		} else if me.less(root.element, element) {
+			root = root.right
+		} else {
+			return true
+		}
+	}
+	return false
+}
+
+// Min returns the SortedSetFunc's first element (in less order) and true;
+// or the zero value and false if the SortedSetFunc is empty.
+func (me *SortedSetFunc[E]) Min() (E, bool) {
+	if me.root == nil {
+		var zero E
+		return zero, false
+	}
+	return first(me.root).element, true
+}
+
+// Max returns the SortedSetFunc's last element (in less order) and true;
+// or the zero value and false if the SortedSetFunc is empty.
+func (me *SortedSetFunc[E]) Max() (E, bool) {
+	if me.root == nil {
+		var zero E
+		return zero, false
+	}
+	return last(me.root).element, true
+}
+
+// Delete deletes the element equal to the given element from the
+// SortedSetFunc and returns true, or does nothing and returns false if
+// there is no such element in the SortedSetFunc.
+func (me *SortedSetFunc[E]) Delete(element E) bool {
+	deleted := false
+	if me.root != nil {
+		if me.root, deleted = me.delete_(me.root, element); me.root != nil {
+			me.root.red = false
+		}
+	}
+	if deleted {
+		me.size--
+	}
+	return deleted
+}
+
+// Take deletes the element equal to x from the SortedSetFunc and returns
+// the deleted (i.e., stored) element and true; or does nothing and returns
+// the zero value and false if there is no such element. The stored element
+// may differ from x, e.g., if the less function only compares one field
+// of a struct.
+func (me *SortedSetFunc[E]) Take(x E) (E, bool) {
+	element, ok := me.Get(x)
+	if ok {
+		me.Delete(x)
+	}
+	return element, ok
+}
+
+// Get returns the stored element equal to x and true; or the zero value
+// and false if there is no such element. The stored element may differ
+// from x, e.g., if the less function only compares one field of a struct.
+// See also [SortedSet.Get].
+func (me *SortedSetFunc[E]) Get(x E) (E, bool) {
+	root := me.root
+	for root != nil {
+		if me.less(x, root.element) {
+			root = root.left
+		} else if me.less(root.element, x) {
+			root = root.right
+		} else {
+			return root.element, true
+		}
+	}
+	var zero E
+	return zero, false
+}
+
+func (me *SortedSetFunc[E]) delete_(root *node[E], element E) (*node[E],
+	bool,
+) {
+	deleted := false
+	if me.less(element, root.element) {
+		if root.left != nil {
+			if !isRed(root.left) && !isRed(root.left.left) {
+				root = moveRedLeft(nil, root)
+			}
+			root.left, deleted = me.delete_(root.left, element)
+		}
+	} else {
+		if isRed(root.left) {
+			root = rotateRight(nil, root)
+		}
+		if me.equal(element, root.element) && root.right == nil {
+			return nil, true
+		}
+		if root.right != nil {
+			root, deleted = me.deleteRight(root, element)
+		}
+	}
+	return fixUp(nil, root), deleted
+}
+
+func (me *SortedSetFunc[E]) deleteRight(root *node[E], element E) (*node[E],
+	bool,
+) {
+	deleted := false
+	if !isRed(root.right) && !isRed(root.right.left) {
+		root = moveRedRight(nil, root)
+	}
+	if me.equal(element, root.element) {
+		smallest := first(root.right)
+		root.element = smallest.element
+		root.right = deleteMinimum(nil, root.right)
+		deleted = true
+	} else {
+		root.right, deleted = me.delete_(root.right, element)
+	}
+	return root, deleted
+}
+
+// Equal returns true if this SortedSetFunc has the same elements as the
+// other SortedSetFunc, judged using this SortedSetFunc's less function;
+// otherwise returns false. This means that elements which are equal
+// according to less are treated as the same even if they differ in other
+// respects (e.g., in struct fields that less ignores).
+//
+// Equal is only meaningful if both SortedSetFuncs order their elements the
+// same way (ideally by using the same less function). Since Go functions
+// can't be compared this isn't checked: if the orders differ the result
+// is unspecified.
+func (me *SortedSetFunc[E]) Equal(other SortedSetFunc[E]) bool {
+	if me.Len() != other.Len() {
+		return false
+	}
+	if me.root == other.root { // other is (a copy of) this SortedSetFunc
+		return true
+	}
+	walker := newWalker(other.root)
+	for element := range me.All() {
+		if otherElement, _ := walker.next(); !me.equal(element,
+			otherElement) {
+			return false
+		}
+	}
+	return true
+}
+
+func (me *SortedSetFunc[E]) equal(a, b E) bool {
+	return !me.less(a, b) && !me.less(b, a)
+}
+
+// Clear deletes all the elements in the SortedSetFunc.
+func (me *SortedSetFunc[E]) Clear() {
+	me.root = nil
+	me.size = 0
+}
+
+// Clone returns a copy of this SortedSetFunc that uses the same less
+// function.
+func (me *SortedSetFunc[E]) Clone() SortedSetFunc[E] {
+	clone := SortedSetFunc[E]{less: me.less}
+	for element := range me.All() {
+		clone.Add(element)
+	}
+	return clone
+}
+
+// ToSlice returns this SortedSetFunc's elements as a sorted slice.
+func (me *SortedSetFunc[E]) ToSlice() []E {
+	slice := make([]E, 0, me.Len())
+	for element := range me.All() {
+		slice = append(slice, element)
+	}
+	return slice
+}
+
+// String returns a human readable string representation of the
+// SortedSetFunc.
+func (me *SortedSetFunc[E]) String() string {
+	format := "%s%v"
+	if isString[E]() {
+		format = "%s%q"
+	}
+	var out strings.Builder
+	out.WriteByte('{')
+	sep := ""
+	for element := range me.All() {
+		fmt.Fprintf(&out, format, sep, element)
+		sep = " "
+	}
+	out.WriteByte('}')
+	return out.String()
+}
@@ -0,0 +1,231 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFuncCaseInsensitive(t *testing.T) {
+	s := NewFunc(func(a, b string) bool {
+		return strings.ToLower(a) < strings.ToLower(b)
+	}, "one", "Two", "THREE", "four", "Five", "ONE", "two")
+	check(s.String(), s.Len(), `{"Five" "four" "one" "THREE" "Two"}`, 5, t)
+	if !s.Contains("three") || !s.Contains("FOUR") || s.Contains("six") {
+		t.Error("unexpected Contains result")
+	}
+	if x, ok := s.Min(); !ok || x != "Five" {
+		t.Errorf("expected \"Five\" true, got %q %t", x, ok)
+	}
+	if x, ok := s.Max(); !ok || x != "Two" {
+		t.Errorf("expected \"Two\" true, got %q %t", x, ok)
+	}
+	if !s.Delete("TWO") || s.Delete("two") {
+		t.Error("unexpected Delete result")
+	}
+	u := s.Clone()
+	u.Add("Six")
+	check(s.String(), s.Len(), `{"Five" "four" "one" "THREE"}`, 4, t)
+	check(u.String(), u.Len(), `{"Five" "four" "one" "Six" "THREE"}`, 5, t)
+	u.Clear()
+	if !u.IsEmpty() {
+		t.Errorf("expected empty set, got %v", u.String())
+	}
+}
+
+func TestFuncStruct(t *testing.T) {
+	type record struct {
+		id   int
+		name string
+	}
+	s := NewFunc(func(a, b record) bool { return a.id < b.id })
+	rng := rand.New(rand.NewSource(1))
+	m := map[int]bool{}
+	for range 5000 {
+		id := rng.Intn(200)
+		if rng.Intn(3) == 0 {
+			if s.Delete(record{id: id}) != m[id] {
+				t.Fatalf("Delete(%d) returned wrong result", id)
+			}
+			delete(m, id)
+		} else {
+			if s.Add(record{id, "x"}) == m[id] {
+				t.Fatalf("Add(%d) returned wrong result", id)
+			}
+			m[id] = true
+		}
+		if s.Len() != len(m) || sizeOf(s.root) != len(m) {
+			t.Fatalf("expected %d elements, got %d", len(m), s.Len())
+		}
+	}
+	prev := -1
+	for element := range s.All() {
+		if element.id <= prev {
+			t.Errorf("out of order: %d after %d", element.id, prev)
+		}
+		prev = element.id
+	}
+	for element := range s.Backward() {
+		if element.id != prev {
+			t.Errorf("expected %d, got %d", prev, element.id)
+		}
+		break
+	}
+}
+
+func TestFuncEqual(t *testing.T) {
+	type record struct {
+		id      int
+		payload string
+	}
+	less := func(a, b record) bool { return a.id < b.id }
+	s := NewFunc(less, record{1, "a"}, record{2, "b"}, record{3, "c"})
+	u := NewFunc(less, record{3, "z"}, record{1, "x"}, record{2, "y"})
+	if !s.Equal(u) || !u.Equal(s) {
+		t.Error("expected sets with the same keys to be equal")
+	}
+	if !s.Equal(s) {
+		t.Error("expected set to equal itself")
+	}
+	u.Delete(record{id: 2})
+	if s.Equal(u) || u.Equal(s) {
+		t.Error("expected sets of different sizes to be unequal")
+	}
+	u.Add(record{4, "b"})
+	if s.Equal(u) || u.Equal(s) {
+		t.Error("expected sets with different keys to be unequal")
+	}
+	empty := NewFunc(less)
+	if !empty.Equal(NewFunc(less)) || empty.Equal(s) {
+		t.Error("unexpected Equal result for empty sets")
+	}
+}
+
+func TestFuncGet(t *testing.T) {
+	type record struct {
+		id   int
+		name string
+	}
+	s := NewFunc(func(a, b record) bool { return a.id < b.id },
+		record{1, "one"}, record{2, "two"})
+	if x, ok := s.Get(record{id: 2}); !ok || x.name != "two" {
+		t.Errorf("expected \"two\" true, got %q %t", x.name, ok)
+	}
+	if x, ok := s.Get(record{id: 3}); ok || x.name != "" {
+		t.Errorf("expected \"\" false, got %q %t", x.name, ok)
+	}
+}
+
+func TestFuncReplace(t *testing.T) {
+	type record struct {
+		id   int
+		name string
+	}
+	s := NewFunc(func(a, b record) bool { return a.id < b.id },
+		record{1, "one"}, record{2, "two"})
+	if old, ok := s.Replace(record{2, "TWO"}); !ok || old.name != "two" {
+		t.Errorf("expected \"two\" true, got %q %t", old.name, ok)
+	}
+	if old, ok := s.Replace(record{3, "three"}); ok || old.name != "" {
+		t.Errorf("expected \"\" false, got %q %t", old.name, ok)
+	}
+	var names []string
+	for element := range s.All() {
+		names = append(names, element.name)
+	}
+	if actual := strings.Join(names, " "); actual != "one TWO three" {
+		t.Errorf("expected \"one TWO three\", got %q", actual)
+	}
+}
+
+func TestNewReversed(t *testing.T) {
+	s := NewReversed(3, 1, 4, 1, 5, 9, 2, 6)
+	check(s.String(), s.Len(), "{9 6 5 4 3 2 1}", 7, t)
+	if x, ok := s.Min(); !ok || x != 9 {
+		t.Errorf("expected 9 true, got %d %t", x, ok)
+	}
+	if x, ok := s.Max(); !ok || x != 1 {
+		t.Errorf("expected 1 true, got %d %t", x, ok)
+	}
+	top := []int{}
+	for x := range s.All() {
+		if len(top) == 3 {
+			break
+		}
+		top = append(top, x)
+	}
+	if fmt.Sprint(top) != "[9 6 5]" {
+		t.Errorf("expected [9 6 5], got %v", top)
+	}
+	words := NewReversed("a", "c", "b")
+	check(words.String(), words.Len(), `{"c" "b" "a"}`, 3, t)
+}
+
+func TestFuncFloatNaN(t *testing.T) {
+	nan := math.NaN()
+	s := NewFunc(cmp.Less[float64], 2.5, nan, -1, math.Inf(1), nan, 0)
+	if s.Len() != 5 {
+		t.Errorf("expected 5 elements, got %d: %v", s.Len(), s.String())
+	}
+	if x, ok := s.Min(); !ok || !math.IsNaN(x) {
+		t.Errorf("expected NaN true, got %g %t", x, ok)
+	}
+	if !s.Contains(nan) || !s.Contains(2.5) || s.Contains(1) {
+		t.Error("unexpected Contains result")
+	}
+	if s.Add(nan) || !s.Delete(nan) || s.Contains(nan) {
+		t.Error("unexpected Add or Delete result for NaN")
+	}
+	check(s.String(), s.Len(), "{-1 0 2.5 +Inf}", 4, t)
+}
+
+func TestFuncNilLess(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	NewFunc[int](nil)
+}
+
+func TestFuncTake(t *testing.T) {
+	type record struct {
+		id   int
+		name string
+	}
+	s := NewFunc(func(a, b record) bool { return a.id < b.id },
+		record{1, "one"}, record{2, "two"}, record{3, "three"})
+	if x, ok := s.Take(record{id: 2}); !ok || x.name != "two" {
+		t.Errorf("expected \"two\" true, got %q %t", x.name, ok)
+	}
+	if x, ok := s.Take(record{id: 2}); ok || x.name != "" {
+		t.Errorf("expected \"\" false, got %q %t", x.name, ok)
+	}
+	if s.Len() != 2 || s.Contains(record{id: 2}) {
+		t.Errorf("expected 2 elements, got %d", s.Len())
+	}
+}
+
+func TestFuncChangedInPlace(t *testing.T) {
+	type record struct{ id int }
+	a, b, c := &record{1}, &record{2}, &record{3}
+	s := NewFunc(func(x, y *record) bool { return x.id < y.id }, c, a, b)
+	if !s.isOrdered() {
+		t.Error("expected ordered")
+	}
+	b.id = 9 // breaks the SortedSetFunc
+	if s.isOrdered() {
+		t.Error("expected out of order")
+	}
+	defer func() {
+		if panicked := recover() != nil; panicked != debug {
+			t.Errorf("expected panic %t, got %t", debug, panicked)
+		}
+	}()
+	for range s.All() {
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"iter"
+	"sync"
+)
+
+// SyncSortedSet is a SortedSet that is safe for concurrent use by multiple
+// goroutines. Methods that only read take a read lock and methods that
+// mutate take a write lock.
+//
+// The iterators ([SyncSortedSet.All], [SyncSortedSet.AllX],
+// [SyncSortedSet.Backward], [SyncSortedSet.BackwardX], and
+// [SyncSortedSet.Between]) copy the relevant elements into a slice under
+// the read lock and then iterate over the copy without holding the lock,
+// so the caller's loop body may safely call other methods (including
+// mutating ones) on the same SyncSortedSet. Such mutations are not seen by
+// the loop.
+//
+// Methods that take an other SortedSet argument (e.g., [SyncSortedSet.Union])
+// take a plain SortedSet; to pass a SyncSortedSet use its
+// [SyncSortedSet.Clone] method.
+//
+// SyncSortedSet zero value is usable. Create with statements like these:
+//
+//	var set SyncSortedSet[string]
+//	set := &SyncSortedSet[int]{}
+//
+// or use [NewSync]:
+//
+//	set := NewSync(1, 2, 4)
+//
+// A SyncSortedSet must not be copied after first use.
+type SyncSortedSet[E Comparable] struct {
+	mutex sync.RWMutex
+	sset  SortedSet[E]
+}
+
+// NewSync returns a new SyncSortedSet containing the given elements (if
+// any).
+func NewSync[E Comparable](elements ...E) *SyncSortedSet[E] {
+	return &SyncSortedSet[E]{sset: New(elements...)}
+}
+
+// Add adds a new element into the SyncSortedSet and returns true; or does
+// nothing and returns false if the element is already present.
+func (me *SyncSortedSet[E]) Add(element E) bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.Add(element)
+}
+
+// AddAll adds each of the given elements that isn't already present into
+// the SyncSortedSet and returns how many were added.
+func (me *SyncSortedSet[E]) AddAll(elements ...E) int {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.AddAll(elements...)
+}
+
+// Delete deletes the given element from the SyncSortedSet and returns
+// true, or does nothing and returns false if the element is not present.
+func (me *SyncSortedSet[E]) Delete(element E) bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.Delete(element)
+}
+
+// DeleteAll deletes each of the given elements that is present from the
+// SyncSortedSet and returns how many were deleted.
+func (me *SyncSortedSet[E]) DeleteAll(elements ...E) int {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.DeleteAll(elements...)
+}
+
+// DeleteMin deletes the SyncSortedSet's smallest element and returns true;
+// or does nothing and returns false if the SyncSortedSet is empty.
+func (me *SyncSortedSet[E]) DeleteMin() bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.DeleteMin()
+}
+
+// DeleteMax deletes the SyncSortedSet's largest element and returns true;
+// or does nothing and returns false if the SyncSortedSet is empty.
+func (me *SyncSortedSet[E]) DeleteMax() bool {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return me.sset.DeleteMax()
+}
+
+// Clear deletes all the elements in the SyncSortedSet.
+func (me *SyncSortedSet[E]) Clear() {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.sset.Clear()
+}
+
+// Unite adds all the elements from other that aren't already in this
+// SyncSortedSet to this SyncSortedSet.
+func (me *SyncSortedSet[E]) Unite(other SortedSet[E]) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.sset.Unite(other)
+}
+
+// Subtract deletes all the elements from this SyncSortedSet that are in
+// the other SortedSet.
+func (me *SyncSortedSet[E]) Subtract(other SortedSet[E]) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.sset.Subtract(other)
+}
+
+// RetainAll deletes all the elements from this SyncSortedSet that are not
+// in the other SortedSet.
+func (me *SyncSortedSet[E]) RetainAll(other SortedSet[E]) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.sset.RetainAll(other)
+}
+
+// SymmetricDifferenceUpdate changes this SyncSortedSet so that it contains
+// only those elements which were in this SyncSortedSet or the other
+// SortedSet—but not in both.
+func (me *SyncSortedSet[E]) SymmetricDifferenceUpdate(other SortedSet[E]) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.sset.SymmetricDifferenceUpdate(other)
+}
+
+// Len returns the number of items in the SyncSortedSet.
+func (me *SyncSortedSet[E]) Len() int {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Len()
+}
+
+// IsEmpty returns true if there are no elements in the SyncSortedSet;
+// otherwise returns false.
+func (me *SyncSortedSet[E]) IsEmpty() bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsEmpty()
+}
+
+// Contains returns true if the element is in the SyncSortedSet; otherwise
+// false.
+func (me *SyncSortedSet[E]) Contains(element E) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Contains(element)
+}
+
+// Min returns the SyncSortedSet's smallest element and true; or the zero
+// value and false if the SyncSortedSet is empty.
+func (me *SyncSortedSet[E]) Min() (E, bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Min()
+}
+
+// Max returns the SyncSortedSet's largest element and true; or the zero
+// value and false if the SyncSortedSet is empty.
+func (me *SyncSortedSet[E]) Max() (E, bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Max()
+}
+
+// Floor returns the largest element that is less than or equal to x and
+// true; or the zero value and false if there is no such element.
+func (me *SyncSortedSet[E]) Floor(x E) (E, bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Floor(x)
+}
+
+// Ceiling returns the smallest element that is greater than or equal to x
+// and true; or the zero value and false if there is no such element.
+func (me *SyncSortedSet[E]) Ceiling(x E) (E, bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Ceiling(x)
+}
+
+// At returns the element at sorted index i (counting from 0) and true; or
+// the zero value and false if i is out of range.
+func (me *SyncSortedSet[E]) At(i int) (E, bool) {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.At(i)
+}
+
+// Rank returns the number of elements that are less than x.
+func (me *SyncSortedSet[E]) Rank(x E) int {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Rank(x)
+}
+
+// CountBetween returns the number of elements that are greater than or
+// equal to lo and less than hi; or 0 if lo > hi.
+func (me *SyncSortedSet[E]) CountBetween(lo, hi E) int {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.CountBetween(lo, hi)
+}
+
+// All returns a for .. range iterable of a snapshot of the SyncSortedSet's
+// elements.
+func (me *SyncSortedSet[E]) All() iter.Seq[E] {
+	return sliceSeq(me.ToSlice())
+}
+
+// AllX returns an iterator of a snapshot of the SyncSortedSet's elements,
+// e.g., for count, element := range sset.AllX(1) ...
+func (me *SyncSortedSet[E]) AllX(start ...int) iter.Seq2[int, E] {
+	return countSeq(sliceSeq(me.ToSlice()), start)
+}
+
+// Backward returns a for .. range iterable of a snapshot of the
+// SyncSortedSet's elements in descending order.
+func (me *SyncSortedSet[E]) Backward() iter.Seq[E] {
+	return sliceBackward(me.ToSlice())
+}
+
+// BackwardX returns an iterator of a snapshot of the SyncSortedSet's
+// elements in descending order.
+func (me *SyncSortedSet[E]) BackwardX(start ...int) iter.Seq2[int, E] {
+	return countSeq(sliceBackward(me.ToSlice()), start)
+}
+
+// Between returns a for .. range iterable of a snapshot of the
+// SyncSortedSet's elements that are greater than or equal to lo and less
+// than hi.
+func (me *SyncSortedSet[E]) Between(lo, hi E) iter.Seq[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	var elements []E
+	for element := range me.sset.Between(lo, hi) {
+		elements = append(elements, element)
+	}
+	return sliceSeq(elements)
+}
+
+func sliceSeq[E any](elements []E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for _, element := range elements {
+			if !yield(element) {
+				return
+			}
+		}
+	}
+}
+
+func sliceBackward[E any](elements []E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for i := len(elements) - 1; i >= 0; i-- {
+			if !yield(elements[i]) {
+				return
+			}
+		}
+	}
+}
+
+// countSeq returns an iterator of seq's elements each paired with a count
+// that starts from start[0] (or 0 if start is empty).
+func countSeq[E any](seq iter.Seq[E], start []int) iter.Seq2[int, E] {
+	return func(yield func(int, E) bool) {
+		i := 0
+		if len(start) > 0 {
+			i = start[0]
+		}
+		for element := range seq {
+			if !yield(i, element) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Difference returns a new SortedSet that contains the elements which are
+// in this SyncSortedSet that are not in the other SortedSet.
+func (me *SyncSortedSet[E]) Difference(other SortedSet[E]) SortedSet[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Difference(other)
+}
+
+// SymmetricDifference returns a new SortedSet that contains the elements
+// which are in this SyncSortedSet or the other SortedSet—but not in both.
+func (me *SyncSortedSet[E]) SymmetricDifference(
+	other SortedSet[E],
+) SortedSet[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.SymmetricDifference(other)
+}
+
+// Intersection returns a new SortedSet that contains the elements this
+// SyncSortedSet has in common with the other SortedSet.
+func (me *SyncSortedSet[E]) Intersection(other SortedSet[E]) SortedSet[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Intersection(other)
+}
+
+// Union returns a new SortedSet that contains the elements from this
+// SyncSortedSet and from the other SortedSet.
+func (me *SyncSortedSet[E]) Union(other SortedSet[E]) SortedSet[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Union(other)
+}
+
+// IsDisjoint returns true if this SyncSortedSet has no elements in common
+// with the other SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) IsDisjoint(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsDisjoint(other)
+}
+
+// IsSubsetOf returns true if this SyncSortedSet is a subset of the other
+// SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) IsSubsetOf(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsSubsetOf(other)
+}
+
+// IsSupersetOf returns true if this SyncSortedSet is a superset of the
+// other SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) IsSupersetOf(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsSupersetOf(other)
+}
+
+// IsProperSubsetOf returns true if this SyncSortedSet is a proper subset
+// of the other SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) IsProperSubsetOf(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsProperSubsetOf(other)
+}
+
+// IsProperSupersetOf returns true if this SyncSortedSet is a proper
+// superset of the other SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) IsProperSupersetOf(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.IsProperSupersetOf(other)
+}
+
+// Equal returns true if this SyncSortedSet has the same elements as the
+// other SortedSet; otherwise returns false.
+func (me *SyncSortedSet[E]) Equal(other SortedSet[E]) bool {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Equal(other)
+}
+
+// Jaccard returns the Jaccard index of this SyncSortedSet and the other
+// SortedSet.
+func (me *SyncSortedSet[E]) Jaccard(other SortedSet[E]) float64 {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Jaccard(other)
+}
+
+// Clone returns a copy of this SyncSortedSet's elements as a plain (not
+// concurrency-safe) SortedSet.
+func (me *SyncSortedSet[E]) Clone() SortedSet[E] {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.Clone()
+}
+
+// ToSlice returns this SyncSortedSet's elements as a sorted slice.
+func (me *SyncSortedSet[E]) ToSlice() []E {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.ToSlice()
+}
+
+// String returns a human readable string representation of the
+// SyncSortedSet.
+func (me *SyncSortedSet[E]) String() string {
+	me.mutex.RLock()
+	defer me.mutex.RUnlock()
+	return me.sset.String()
+}
@@ -0,0 +1,99 @@
+// Copyright © 2024-25 Mark Summerfield. All rights reserved.
+package sortedset
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSyncConcurrent(t *testing.T) {
+	s := NewSync[int]()
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				s.Add(g*1000 + i)
+				s.Contains(i)
+				if i%3 == 0 {
+					s.Delete(g*1000 + i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Len() != 8*666 {
+		t.Errorf("expected %d elements, got %d", 8*666, s.Len())
+	}
+	u := s.Clone()
+	if !u.IsValid() || !s.Equal(u) {
+		t.Errorf("unexpected unequal clone")
+	}
+}
+
+func TestSyncIterationMutation(t *testing.T) {
+	s := NewSync(1, 2, 3, 4, 5)
+	for element := range s.All() { // must not deadlock
+		s.Add(element * 10)
+	}
+	check(s.String(), s.Len(), "{1 2 3 4 5 10 20 30 40 50}", 10, t)
+	n := 0
+	for element := range s.Backward() {
+		s.Delete(element)
+		n++
+	}
+	check(s.String(), s.Len(), "{}", 0, t)
+	if n != 10 {
+		t.Errorf("expected 10, got %d", n)
+	}
+	s.AddAll(1, 2, 3, 4, 5)
+	n = 0
+	for element := range s.Between(2, 5) {
+		n += element
+		s.Clear()
+	}
+	if n != 9 {
+		t.Errorf("expected 9, got %d", n)
+	}
+}
+
+func TestSyncCountedIteration(t *testing.T) {
+	s := NewSync(3, 1, 2)
+	var forward, backward []int
+	for i, element := range s.AllX(1) {
+		forward = append(forward, i, element)
+		s.Add(element + 10) // not seen by the loop
+	}
+	for i, element := range s.BackwardX() {
+		backward = append(backward, i, element)
+		if i == 2 {
+			break
+		}
+	}
+	if !slices.Equal(forward, []int{1, 1, 2, 2, 3, 3}) {
+		t.Errorf("expected [1 1 2 2 3 3], got %v", forward)
+	}
+	if !slices.Equal(backward, []int{0, 13, 1, 12, 2, 11}) {
+		t.Errorf("expected [0 13 1 12 2 11], got %v", backward)
+	}
+}
+
+func TestSyncAPI(t *testing.T) {
+	var s SyncSortedSet[int]
+	s.AddAll(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	u := New(2, 4, 6, 8, 10)
+	x := s.Union(u)
+	check(x.String(), x.Len(), "{0 1 2 3 4 5 6 7 8 9 10}", 11, t)
+	x = s.Intersection(u)
+	check(x.String(), x.Len(), "{2 4 6 8}", 4, t)
+	if x, ok := s.At(3); !ok || x != 3 {
+		t.Errorf("expected 3 true, got %d %t", x, ok)
+	}
+	s.Subtract(u)
+	check(s.String(), s.Len(), "{0 1 3 5 7 9}", 6, t)
+	if !s.IsDisjoint(u) || s.IsSubsetOf(u) {
+		t.Error("unexpected disjoint or subset result")
+	}
+}